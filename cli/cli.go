@@ -8,13 +8,17 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/danielmiessler/fabric/plugins/tools/subtitles"
 	"github.com/danielmiessler/fabric/plugins/tools/youtube"
 
 	"github.com/danielmiessler/fabric/common"
 	"github.com/danielmiessler/fabric/core"
 	"github.com/danielmiessler/fabric/plugins/ai"
 	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/template"
 	"github.com/danielmiessler/fabric/plugins/tools/converter"
+	"github.com/danielmiessler/fabric/plugins/tools/scraper"
+	"github.com/danielmiessler/fabric/plugins/tools/suggest"
 	"github.com/danielmiessler/fabric/restapi"
 )
 
@@ -67,6 +71,12 @@ func Cli(version string) (err error) {
 		return
 	}
 
+	// ask for missing pattern variables only when a user can answer, never
+	// in the servers above where stdin belongs to no request
+	if IsStdinTerminal() {
+		fabricDb.Patterns.VariablePrompter = PromptVariables
+	}
+
 	if currentFlags.UpdatePatterns {
 		err = registry.PatternsLoader.PopulateDB()
 		return
@@ -150,7 +160,12 @@ func Cli(version string) (err error) {
 	}
 
 	if currentFlags.AddExtension != "" {
-		err = registry.TemplateExtensions.RegisterExtension(currentFlags.AddExtension)
+		err = registry.TemplateExtensions.InstallExtension(currentFlags.AddExtension)
+		return
+	}
+
+	if currentFlags.UpdateExtension != "" {
+		err = registry.TemplateExtensions.UpdateExtension(currentFlags.UpdateExtension)
 		return
 	}
 
@@ -169,6 +184,31 @@ func Cli(version string) (err error) {
 		return
 	}
 
+	if currentFlags.ListTemplatePlugins {
+		err = template.PrintPlugins(os.Stdout)
+		return
+	}
+
+	if currentFlags.IndexContext != "" {
+		var embedder ai.Embedder
+		var model string
+		if embedder, model, err = registry.GetEmbedder(""); err != nil {
+			return
+		}
+		err = IndexContext(fabricDb.Contexts, currentFlags.IndexContext, embedder, model)
+		return
+	}
+
+	if currentFlags.Embed {
+		var embedder ai.Embedder
+		var model string
+		if embedder, model, err = registry.GetEmbedder(currentFlags.Model); err != nil {
+			return
+		}
+		err = Embed(currentFlags, embedder, model)
+		return
+	}
+
 	// if the interactive flag is set, run the interactive function
 	// if currentFlags.Interactive {
 	// 	interactive.Interactive()
@@ -225,11 +265,48 @@ func Cli(version string) (err error) {
 		}
 	}
 
-	if (currentFlags.ScrapeURL != "" || currentFlags.ScrapeQuestion != "") && registry.Jina.IsConfigured() {
+	if currentFlags.Subtitles != "" {
+		var transcript string
+		if transcript, err = subtitles.ReadFile(currentFlags.Subtitles, currentFlags.YouTubeTranscriptWithTimestamps); err != nil {
+			return
+		}
+		messageTools = AppendMessage(messageTools, transcript)
+
+		if !currentFlags.IsChatRequest() {
+			err = currentFlags.WriteOutput(messageTools)
+			return
+		}
+	}
+
+	if currentFlags.Transcribe != "" {
+		var transcriber ai.Transcriber
+		var model string
+		if transcriber, model, err = registry.GetTranscriber(currentFlags.TranscribeModel); err != nil {
+			return
+		}
+
+		var transcript string
+		if transcript, err = Transcribe(currentFlags, transcriber, model, registry.Language.DefaultLanguage.Value); err != nil {
+			return
+		}
+		messageTools = AppendMessage(messageTools, transcript)
+
+		if !currentFlags.IsChatRequest() {
+			err = currentFlags.WriteOutput(messageTools)
+			return
+		}
+	}
+
+	if currentFlags.ScrapeURL != "" || currentFlags.ScrapeQuestion != "" {
 		// Check if the scrape_url flag is set and call ScrapeURL
 		if currentFlags.ScrapeURL != "" {
+			var webScraper scraper.Scraper
+			if webScraper, err = registry.GetScraper(currentFlags.Scraper); err != nil {
+				return
+			}
+
 			var website string
-			if website, err = registry.Jina.ScrapeURL(currentFlags.ScrapeURL); err != nil {
+			if website, err = webScraper.ScrapeURL(currentFlags.ScrapeURL); err != nil {
 				return
 			}
 			messageTools = AppendMessage(messageTools, website)
@@ -237,6 +314,12 @@ func Cli(version string) (err error) {
 
 		// Check if the scrape_question flag is set and call ScrapeQuestion
 		if currentFlags.ScrapeQuestion != "" {
+			// questions are only answered by Jina AI, which is not contacted
+			// unless it was set up
+			if registry.Jina.ApiKey.Value == "" {
+				err = fmt.Errorf("--scrape_question uses Jina AI, set its API key with fabric --setup first")
+				return
+			}
 			var website string
 			if website, err = registry.Jina.ScrapeQuestion(currentFlags.ScrapeQuestion); err != nil {
 				return
@@ -255,6 +338,20 @@ func Cli(version string) (err error) {
 		currentFlags.AppendMessage(messageTools)
 	}
 
+	if currentFlags.Suggest || currentFlags.SuggestRun {
+		var suggestions []*suggest.Suggestion
+		if suggestions, err = SuggestPatterns(currentFlags, registry, fabricDb.Patterns); err != nil {
+			return
+		}
+		if !currentFlags.SuggestRun {
+			PrintSuggestions(os.Stdout, suggestions, currentFlags.ShellCompleteOutput)
+			return
+		}
+		PrintSuggestions(os.Stderr, suggestions, false)
+		fmt.Fprintf(os.Stderr, "\nRunning pattern %s\n\n", suggestions[0].Name)
+		currentFlags.Pattern = suggestions[0].Name
+	}
+
 	var chatter *core.Chatter
 	if chatter, err = registry.GetChatter(currentFlags.Model, currentFlags.ModelContextLength, currentFlags.Strategy, currentFlags.Stream, currentFlags.DryRun); err != nil {
 		return
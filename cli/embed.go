@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/danielmiessler/fabric/plugins/ai"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+// Embedding is the vector of one input of --embed
+type Embedding struct {
+	Source    string    `json:"source"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// EmbeddingsOutput is printed by --embed
+type EmbeddingsOutput struct {
+	Model      string      `json:"model"`
+	Embeddings []Embedding `json:"embeddings"`
+}
+
+// Embed prints the embeddings of the message and the embed files as JSON
+func Embed(flags *Flags, embedder ai.Embedder, model string) (err error) {
+	var sources, inputs []string
+	if sources, inputs, err = flags.embeddingInputs(); err != nil {
+		return
+	}
+
+	var vectors [][]float32
+	if vectors, err = embedder.Embed(context.Background(), inputs, model); err != nil {
+		return
+	}
+
+	output := EmbeddingsOutput{Model: model}
+	for i, vector := range vectors {
+		output.Embeddings = append(output.Embeddings, Embedding{Source: sources[i], Embedding: vector})
+	}
+
+	var data []byte
+	if data, err = json.Marshal(output); err != nil {
+		return
+	}
+	err = flags.WriteOutput(string(data))
+	return
+}
+
+// embeddingInputs returns the texts to embed, the message from stdin or the
+// command line is named "input" and files are named by their path
+func (o *Flags) embeddingInputs() (sources []string, inputs []string, err error) {
+	if o.Message != "" {
+		sources = append(sources, "input")
+		inputs = append(inputs, o.Message)
+	}
+
+	for _, file := range o.EmbedFiles {
+		var content []byte
+		if content, err = os.ReadFile(file); err != nil {
+			err = fmt.Errorf("could not read %s: %w", file, err)
+			return
+		}
+		sources = append(sources, file)
+		inputs = append(inputs, string(content))
+	}
+
+	if len(inputs) == 0 {
+		err = fmt.Errorf("nothing to embed, pipe text to fabric or use --embed-file")
+	}
+	return
+}
+
+// IndexContext updates the vector store of a document context
+func IndexContext(contexts *fsdb.ContextsEntity, name string, embedder ai.Embedder, model string) (err error) {
+	if !contexts.IsDocuments(name) {
+		err = fmt.Errorf("context %s is not a directory of documents", name)
+		return
+	}
+
+	var index *fsdb.DocumentIndex
+	var updated []string
+	if index, updated, err = contexts.IndexDocuments(context.Background(), name, model,
+		func(ctx context.Context, inputs []string) ([][]float32, error) {
+			return embedder.Embed(ctx, inputs, model)
+		}); err != nil {
+		return
+	}
+
+	fmt.Printf("Context %s: %d documents indexed with %s, %d updated\n", name, len(index.Files), model, len(updated))
+	return
+}
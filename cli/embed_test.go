@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEmbedder struct {
+	inputs []string
+}
+
+func (o *fakeEmbedder) Embed(_ context.Context, inputs []string, _ string) (ret [][]float32, err error) {
+	o.inputs = inputs
+	for _, input := range inputs {
+		ret = append(ret, []float32{float32(len(input))})
+	}
+	return
+}
+
+func TestEmbed(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.md")
+	require.NoError(t, os.WriteFile(file, []byte("file text"), 0644))
+	output := filepath.Join(dir, "out.json")
+
+	embedder := &fakeEmbedder{}
+	flags := &Flags{Message: "hello", EmbedFiles: []string{file}, Output: output}
+	require.NoError(t, Embed(flags, embedder, "embed-model"))
+	assert.Equal(t, []string{"hello", "file text"}, embedder.inputs)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"model": "embed-model", "embeddings": [
+		{"source": "input", "embedding": [5]},
+		{"source": "`+file+`", "embedding": [9]}]}`, string(data))
+
+	assert.Error(t, Embed(&Flags{}, embedder, "embed-model"))
+	assert.Error(t, Embed(&Flags{EmbedFiles: []string{filepath.Join(dir, "missing")}}, embedder, "embed-model"))
+}
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
 
 	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/plugins/template"
+	"github.com/danielmiessler/fabric/plugins/tools/converter"
 	"github.com/jessevdk/go-flags"
 	goopenai "github.com/sashabaranov/go-openai"
 	"golang.org/x/text/language"
@@ -20,10 +23,12 @@ import (
 // Flags create flags struct. the users flags go into this, this will be passed to the chat struct in cli
 type Flags struct {
 	Pattern                         string            `short:"p" long:"pattern" yaml:"pattern" description:"Choose a pattern from the available patterns" default:""`
-	PatternVariables                map[string]string `short:"v" long:"variable" description:"Values for pattern variables, e.g. -v=#role:expert -v=#points:30"`
-	Context                         string            `short:"C" long:"context" description:"Choose a context from the available contexts" default:""`
+	PatternVariables                map[string]string `short:"v" long:"variable" description:"Values for pattern variables, e.g. -v=#role:expert -v=#points:30 -v=#notes:@notes.md"`
+	Context                         []string          `short:"C" long:"context" description:"Choose a context from the available contexts, can be repeated to combine contexts in order"`
+	ContextTopK                     int               `long:"context-top-k" yaml:"contextTopK" description:"Number of chunks retrieved from a document context (a directory of documents)" default:"5"`
+	IndexContext                    string            `long:"index-context" description:"Index the documents of a document context with the default embedding model"`
 	Session                         string            `long:"session" description:"Choose a session from the available sessions"`
-	Attachments                     []string          `short:"a" long:"attachment" description:"Attachment path or URL (e.g. for OpenAI image recognition messages)"`
+	Attachments                     []string          `short:"a" long:"attachment" description:"Attachment path or URL: an image, or a PDF, DOCX, EPUB, HTML or text document"`
 	Setup                           bool              `short:"S" long:"setup" description:"Run setup for all reconfigurable parts of fabric"`
 	Temperature                     float64           `short:"t" long:"temperature" yaml:"temperature" description:"Set temperature" default:"0.7"`
 	TopP                            float64           `short:"T" long:"topp" yaml:"topp" description:"Set top P" default:"0.9"`
@@ -47,12 +52,16 @@ type Flags struct {
 	YouTube                         string            `short:"y" long:"youtube" description:"YouTube video or play list \"URL\" to grab transcript, comments from it and send to chat or print it put to the console and store it in the output file"`
 	YouTubePlaylist                 bool              `long:"playlist" description:"Prefer playlist over video if both ids are present in the URL"`
 	YouTubeTranscript               bool              `long:"transcript" description:"Grab transcript from YouTube video and send to chat (it is used per default)."`
-	YouTubeTranscriptWithTimestamps bool              `long:"transcript-with-timestamps" description:"Grab transcript from YouTube video with timestamps and send to chat"`
+	YouTubeTranscriptWithTimestamps bool              `long:"transcript-with-timestamps" description:"Grab transcript from YouTube video, --subtitles or --transcribe file with timestamps and send to chat"`
 	YouTubeComments                 bool              `long:"comments" description:"Grab comments from YouTube video and send to chat"`
 	YouTubeMetadata                 bool              `long:"metadata" description:"Output video metadata"`
+	Subtitles                       string            `long:"subtitles" description:"SRT, WebVTT or YouTube XML transcript file to send to chat or print, with timestamps when --transcript-with-timestamps is set"`
+	Transcribe                      string            `long:"transcribe" description:"Audio or video file to transcribe and send to chat or print, with timestamps when --transcript-with-timestamps is set, files over 25 MB are split with ffmpeg"`
+	TranscribeModel                 string            `long:"transcribe-model" yaml:"transcribeModel" description:"Transcription model, e.g. whisper-1, the default transcription model is used when empty"`
 	Language                        string            `short:"g" long:"language" description:"Specify the Language Code for the chat, e.g. -g=en -g=zh" default:""`
-	ScrapeURL                       string            `short:"u" long:"scrape_url" description:"Scrape website URL to markdown using Jina AI"`
-	ScrapeQuestion                  string            `short:"q" long:"scrape_question" description:"Search question using Jina AI"`
+	ScrapeURL                       string            `short:"u" long:"scrape_url" description:"Scrape website URL to markdown, locally or using Jina AI"`
+	ScrapeQuestion                  string            `short:"q" long:"scrape_question" description:"Search question using Jina AI, requires its API key"`
+	Scraper                         string            `long:"scraper" description:"Scraper backend of --scrape_url, local or jina, the default backend is used when empty"`
 	Seed                            int               `short:"e" long:"seed" yaml:"seed" description:"Seed to be used for LMM generation"`
 	WipeContext                     string            `short:"w" long:"wipecontext" description:"Wipe context"`
 	WipeSession                     string            `short:"W" long:"wipesession" description:"Wipe session"`
@@ -68,11 +77,18 @@ type Flags struct {
 	Config                          string            `long:"config" description:"Path to YAML config file"`
 	Version                         bool              `long:"version" description:"Print current version"`
 	ListExtensions                  bool              `long:"listextensions" description:"List all registered extensions"`
-	AddExtension                    string            `long:"addextension" description:"Register a new extension from config file path"`
+	AddExtension                    string            `long:"addextension" description:"Register a new extension from a config file path, or install it from a directory or git URL"`
+	UpdateExtension                 string            `long:"update-extension" description:"Update an installed extension from its directory or git repository"`
 	RemoveExtension                 string            `long:"rmextension" description:"Remove a registered extension by name"`
 	Strategy                        string            `long:"strategy" description:"Choose a strategy from the available strategies" default:""`
 	ListStrategies                  bool              `long:"liststrategies" description:"List all strategies"`
 	ListVendors                     bool              `long:"listvendors" description:"List all vendors"`
+	ListTemplatePlugins             bool              `long:"list-template-plugins" description:"List all template plugin namespaces and their operations"`
+	Suggest                         bool              `long:"suggest" description:"Suggest the patterns best matching the input or question"`
+	SuggestCount                    int               `long:"suggest-count" description:"Number of patterns to suggest" default:"5"`
+	SuggestRun                      bool              `long:"suggest-run" description:"Suggest patterns and run the best one with the input"`
+	Embed                           bool              `long:"embed" description:"Output embeddings of the input and --embed-file files as JSON, using --model or the default embedding model"`
+	EmbedFiles                      []string          `long:"embed-file" description:"File to embed with --embed, can be repeated"`
 	ShellCompleteOutput             bool              `long:"shell-complete-list" description:"Output raw list without headers/formatting (for shell completion)"`
 }
 
@@ -269,13 +285,16 @@ func (o *Flags) BuildChatOptions() (ret *common.ChatOptions) {
 
 func (o *Flags) BuildChatRequest(Meta string) (ret *common.ChatRequest, err error) {
 	ret = &common.ChatRequest{
-		ContextName:      o.Context,
-		SessionName:      o.Session,
-		PatternName:      o.Pattern,
-		StrategyName:     o.Strategy,
-		PatternVariables: o.PatternVariables,
-		InputHasVars:     o.InputHasVars,
-		Meta:             Meta,
+		ContextNames: o.Context,
+		ContextTopK:  o.ContextTopK,
+		SessionName:  o.Session,
+		PatternName:  o.Pattern,
+		StrategyName: o.Strategy,
+		InputHasVars: o.InputHasVars,
+		Meta:         Meta,
+	}
+	if ret.PatternVariables, err = template.LoadVariableFiles(o.PatternVariables); err != nil {
+		return
 	}
 
 	var message *goopenai.ChatCompletionMessage
@@ -287,42 +306,74 @@ func (o *Flags) BuildChatRequest(Meta string) (ret *common.ChatRequest, err erro
 			}
 		}
 	} else {
-		message = &goopenai.ChatCompletionMessage{
-			Role: goopenai.ChatMessageRoleUser,
-		}
-
+		// documents are appended to the text as it is, images are sent as
+		// image parts
+		texts := []string{}
 		if o.Message != "" {
-			message.MultiContent = append(message.MultiContent, goopenai.ChatMessagePart{
-				Type: goopenai.ChatMessagePartTypeText,
-				Text: strings.TrimSpace(o.Message),
-			})
+			texts = append(texts, strings.TrimSpace(o.Message))
 		}
 
+		var imageParts []goopenai.ChatMessagePart
 		for _, attachmentValue := range o.Attachments {
 			var attachment *common.Attachment
 			if attachment, err = common.NewAttachment(attachmentValue); err != nil {
 				return
 			}
+			var mimeType string
+			if mimeType, err = attachment.ResolveType(); err != nil {
+				return
+			}
+
+			if !strings.HasPrefix(mimeType, "image/") {
+				if !converter.IsDocument(mimeType) {
+					err = fmt.Errorf("attachment %s has the unsupported type %s", attachmentValue, mimeType)
+					return
+				}
+				var content []byte
+				if content, err = attachment.ContentBytes(); err != nil {
+					return
+				}
+				var text string
+				if text, err = converter.ExtractText(content, mimeType); err != nil {
+					err = fmt.Errorf("could not extract the text of %s: %w", attachmentValue, err)
+					return
+				}
+				texts = append(texts, fmt.Sprintf("## File: %s\n\n%s", filepath.Base(attachmentValue), text))
+				continue
+			}
+
 			url := attachment.URL
 			if url == nil {
 				var base64Image string
 				if base64Image, err = attachment.Base64Content(); err != nil {
 					return
 				}
-				var mimeType string
-				if mimeType, err = attachment.ResolveType(); err != nil {
-					return
-				}
 				dataURL := fmt.Sprintf("data:%s;base64,%s", mimeType, base64Image)
 				url = &dataURL
 			}
-			message.MultiContent = append(message.MultiContent, goopenai.ChatMessagePart{
+			imageParts = append(imageParts, goopenai.ChatMessagePart{
 				Type: goopenai.ChatMessagePartTypeImageURL,
 				ImageURL: &goopenai.ChatMessageImageURL{
 					URL: *url,
 				},
 			})
 		}
+
+		message = &goopenai.ChatCompletionMessage{
+			Role: goopenai.ChatMessageRoleUser,
+		}
+		text := strings.Join(texts, "\n\n")
+		if len(imageParts) == 0 {
+			message.Content = text
+		} else {
+			if text != "" {
+				message.MultiContent = append(message.MultiContent, goopenai.ChatMessagePart{
+					Type: goopenai.ChatMessagePartTypeText,
+					Text: text,
+				})
+			}
+			message.MultiContent = append(message.MultiContent, imageParts...)
+		}
 	}
 	ret.Message = message
 
@@ -339,7 +390,7 @@ func (o *Flags) AppendMessage(message string) {
 }
 
 func (o *Flags) IsChatRequest() (ret bool) {
-	ret = o.Message != "" || len(o.Attachments) > 0 || o.Context != "" || o.Session != "" || o.Pattern != ""
+	ret = o.Message != "" || len(o.Attachments) > 0 || len(o.Context) > 0 || o.Session != "" || o.Pattern != ""
 	return
 }
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -164,3 +165,35 @@ model: 123  # should be string
 		assert.Error(t, err)
 	})
 }
+
+func TestBuildChatRequestDocumentAttachments(t *testing.T) {
+	dir := t.TempDir()
+	htmlPath := filepath.Join(dir, "notes.html")
+	assert.NoError(t, os.WriteFile(htmlPath, []byte("<html><body><p>Meeting notes</p></body></html>"), 0644))
+	imagePath := filepath.Join(dir, "image.png")
+	assert.NoError(t, os.WriteFile(imagePath, []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), 0644))
+
+	flags := &Flags{Message: "Summarize", Attachments: []string{htmlPath}}
+	request, err := flags.BuildChatRequest("")
+	assert.NoError(t, err)
+	assert.Equal(t, "Summarize\n\n## File: notes.html\n\nMeeting notes", request.Message.Content)
+	assert.Empty(t, request.Message.MultiContent)
+
+	flags.Attachments = append(flags.Attachments, imagePath)
+	request, err = flags.BuildChatRequest("")
+	assert.NoError(t, err)
+	assert.Empty(t, request.Message.Content)
+	assert.Len(t, request.Message.MultiContent, 2)
+	assert.Equal(t, "Summarize\n\n## File: notes.html\n\nMeeting notes", request.Message.MultiContent[0].Text)
+	assert.True(t, strings.HasPrefix(request.Message.MultiContent[1].ImageURL.URL, "data:image/png;base64,"))
+}
+
+func TestBuildChatRequestVariableFiles(t *testing.T) {
+	notesPath := filepath.Join(t.TempDir(), "notes.md")
+	assert.NoError(t, os.WriteFile(notesPath, []byte("file notes"), 0644))
+
+	flags := &Flags{PatternVariables: map[string]string{"notes": "@" + notesPath, "author": "@handle"}}
+	request, err := flags.BuildChatRequest("")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"notes": "file notes", "author": "@handle"}, request.PatternVariables)
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/danielmiessler/fabric/plugins/template"
+)
+
+// IsStdinTerminal reports whether stdin is attached to a terminal
+func IsStdinTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return (info.Mode() & os.ModeCharDevice) != 0
+}
+
+// PromptVariables asks for the values of missing pattern variables on stdin
+func PromptVariables(missing []template.VariableDef) (map[string]string, error) {
+	return promptVariables(os.Stdin, os.Stderr, missing)
+}
+
+func promptVariables(in io.Reader, out io.Writer, missing []template.VariableDef) (ret map[string]string, err error) {
+	reader := bufio.NewReader(in)
+	ret = make(map[string]string, len(missing))
+	for _, def := range missing {
+		prompt := def.Name
+		if def.Description != "" {
+			prompt = fmt.Sprintf("%s (%s)", prompt, def.Description)
+		}
+		if len(def.Enum) > 0 {
+			prompt = fmt.Sprintf("%s [%s]", prompt, strings.Join(def.Enum, "|"))
+		}
+		fmt.Fprintf(out, "%s: ", prompt)
+
+		var line string
+		if line, err = reader.ReadString('\n'); err != nil && err != io.EOF {
+			err = fmt.Errorf("could not read value for variable %s: %w", def.Name, err)
+			return
+		}
+		err = nil
+		if line = strings.TrimSpace(line); line != "" {
+			ret[def.Name] = line
+		}
+	}
+	return
+}
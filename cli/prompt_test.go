@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/plugins/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromptVariables(t *testing.T) {
+	in := strings.NewReader("expert\n\n")
+	var out bytes.Buffer
+
+	values, err := promptVariables(in, &out, []template.VariableDef{
+		{Name: "role", Description: "Reviewer role"},
+		{Name: "lang", Enum: []string{"en", "de"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"role": "expert"}, values)
+	assert.Equal(t, "role (Reviewer role): lang [en|de]: ", out.String())
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/danielmiessler/fabric/core"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/suggest"
+)
+
+// SuggestPatterns ranks the patterns for the message, by embeddings when a
+// default embedding model is configured and by keywords otherwise
+func SuggestPatterns(flags *Flags, registry *core.PluginRegistry, patterns *fsdb.PatternsEntity) (ret []*suggest.Suggestion, err error) {
+	if flags.Message == "" {
+		err = fmt.Errorf("nothing to suggest patterns for, pipe text to fabric or pass a question")
+		return
+	}
+
+	var all []*fsdb.Pattern
+	if all, err = patterns.GetAll(); err != nil {
+		return
+	}
+
+	ranked := false
+	if embedder, model, embedderErr := registry.GetEmbedder(""); embedderErr == nil {
+		embed := func(ctx context.Context, inputs []string) ([][]float32, error) {
+			return embedder.Embed(ctx, inputs, model)
+		}
+		if ret, err = suggest.RankEmbeddings(context.Background(), patterns, all, flags.Message, model, embed); err == nil {
+			ranked = true
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: could not rank patterns with %s, using keywords: %v\n", model, err)
+			err = nil
+		}
+	}
+	if !ranked {
+		ret = suggest.RankKeywords(all, flags.Message)
+	}
+
+	if count := flags.SuggestCount; count > 0 && len(ret) > count {
+		ret = ret[:count]
+	}
+	if len(ret) == 0 {
+		err = fmt.Errorf("no pattern matches the input")
+	}
+	return
+}
+
+// PrintSuggestions writes the suggested patterns with their descriptions,
+// only the names for shell completion
+func PrintSuggestions(out io.Writer, suggestions []*suggest.Suggestion, shellCompleteList bool) {
+	width := 0
+	for _, suggestion := range suggestions {
+		width = max(width, len(suggestion.Name))
+	}
+
+	for _, suggestion := range suggestions {
+		if shellCompleteList {
+			fmt.Fprintln(out, suggestion.Name)
+		} else {
+			fmt.Fprintf(out, "%-*s  %.2f  %s\n", width, suggestion.Name, suggestion.Score, suggestion.Description)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/danielmiessler/fabric/plugins/ai"
+	"github.com/danielmiessler/fabric/plugins/tools/transcribe"
+	"golang.org/x/text/language"
+)
+
+// Transcribe returns the transcript of the --transcribe file, in the
+// language of --language or defaultLanguage when one is set
+func Transcribe(flags *Flags, transcriber ai.Transcriber, model string, defaultLanguage string) (ret string, err error) {
+	ret, err = transcribe.Transcribe(context.Background(), transcriber, flags.Transcribe, model,
+		transcriptionLanguage(flags.Language, defaultLanguage), flags.YouTubeTranscriptWithTimestamps)
+	return
+}
+
+// transcriptionLanguage returns the ISO-639-1 code transcription endpoints
+// expect, e.g. "en" for "en-US"
+func transcriptionLanguage(languages ...string) string {
+	for _, value := range languages {
+		if value == "" {
+			continue
+		}
+		if tag, err := language.Parse(value); err == nil {
+			base, _ := tag.Base()
+			return base.String()
+		}
+	}
+	return ""
+}
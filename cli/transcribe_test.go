@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranscriptionLanguage(t *testing.T) {
+	tests := []struct {
+		languages []string
+		expected  string
+	}{
+		{[]string{"en-US", "de"}, "en"},
+		{[]string{"", "de"}, "de"},
+		{[]string{"", ""}, ""},
+		{[]string{"not a language!", "fr"}, "fr"},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, transcriptionLanguage(tc.languages...))
+	}
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/gabriel-vasile/mimetype"
 )
@@ -169,3 +171,46 @@ func detectMimeTypeFromFile(path string) (string, error) {
 func isURL(value string) bool {
 	return bytes.Contains([]byte(value), []byte("://"))
 }
+
+// MaxImageDownloadSize limits images downloaded by DecodeImageURL
+const MaxImageDownloadSize = 20 * 1024 * 1024
+
+// DecodeImageURL returns the content of an image part of a message: data URLs
+// are decoded and other URLs downloaded, for vendors that only accept inline
+// image data
+func DecodeImageURL(ctx context.Context, url string) (mimeType string, data []byte, err error) {
+	if header, encoded, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ";base64,"); ok && strings.HasPrefix(url, "data:") {
+		mimeType = header
+		if data, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			err = fmt.Errorf("invalid image data: %w", err)
+		}
+		return
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil); err != nil {
+		return
+	}
+	var resp *http.Response
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		err = fmt.Errorf("failed to download image %s: %w", url, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("failed to download image %s: %s", url, resp.Status)
+		return
+	}
+	if data, err = io.ReadAll(io.LimitReader(resp.Body, MaxImageDownloadSize+1)); err != nil {
+		return
+	}
+	if len(data) > MaxImageDownloadSize {
+		err = fmt.Errorf("image %s exceeds %d bytes", url, MaxImageDownloadSize)
+		return
+	}
+	if mimeType = resp.Header.Get("Content-Type"); mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = mimetype.Detect(data).String()
+	}
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	return
+}
@@ -5,7 +5,8 @@ import goopenai "github.com/sashabaranov/go-openai"
 const ChatMessageRoleMeta = "meta"
 
 type ChatRequest struct {
-	ContextName      string
+	ContextNames     []string
+	ContextTopK      int
 	SessionName      string
 	PatternName      string
 	PatternVariables map[string]string
@@ -32,7 +33,7 @@ func NormalizeMessages(msgs []*goopenai.ChatCompletionMessage, defaultUserMessag
 	// Iterate over messages to enforce the odd position rule for user messages
 	fullMessageIndex := 0
 	for _, message := range msgs {
-		if message.Content == "" {
+		if message.Content == "" && len(message.MultiContent) == 0 {
 			// Skip empty messages as the anthropic API doesn't accept them
 			continue
 		}
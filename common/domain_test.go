@@ -25,3 +25,15 @@ func TestNormalizeMessages(t *testing.T) {
 	actual := NormalizeMessages(msgs, "default")
 	assert.Equal(t, expected, actual)
 }
+
+func TestNormalizeMessagesKeepsMultiContent(t *testing.T) {
+	image := &goopenai.ChatCompletionMessage{
+		Role: goopenai.ChatMessageRoleUser,
+		MultiContent: []goopenai.ChatMessagePart{
+			{Type: goopenai.ChatMessagePartTypeImageURL, ImageURL: &goopenai.ChatMessageImageURL{URL: "https://example.com/a.png"}},
+		},
+	}
+
+	actual := NormalizeMessages([]*goopenai.ChatCompletionMessage{image}, "default")
+	assert.Equal(t, []*goopenai.ChatCompletionMessage{image}, actual)
+}
@@ -37,9 +37,9 @@ complete -c fabric -f
 # Flag completions with arguments
 complete -c fabric -s p -l pattern -d "Choose a pattern from the available patterns" -a "(__fabric_get_patterns)"
 complete -c fabric -s v -l variable -d "Values for pattern variables, e.g. -v=#role:expert -v=#points:30"
-complete -c fabric -s C -l context -d "Choose a context from the available contexts" -a "(__fabric_get_contexts)"
+complete -c fabric -s C -l context -d "Choose a context from the available contexts, can be repeated to combine contexts in order" -a "(__fabric_get_contexts)"
 complete -c fabric -l session -d "Choose a session from the available sessions" -a "(__fabric_get_sessions)"
-complete -c fabric -s a -l attachment -d "Attachment path or URL (e.g. for OpenAI image recognition messages)" -r
+complete -c fabric -s a -l attachment -d "Attachment path or URL: an image, or a PDF, DOCX, EPUB, HTML or text document" -r
 complete -c fabric -s t -l temperature -d "Set temperature (default: 0.7)"
 complete -c fabric -s T -l topp -d "Set top P (default: 0.9)"
 complete -c fabric -s P -l presencepenalty -d "Set presence penalty (default: 0.0)"
@@ -50,17 +50,21 @@ complete -c fabric -s o -l output -d "Output to file" -r
 complete -c fabric -s n -l latest -d "Number of latest patterns to list (default: 0)"
 complete -c fabric -s y -l youtube -d "YouTube video or play list URL to grab transcript, comments from it"
 complete -c fabric -s g -l language -d "Specify the Language Code for the chat, e.g. -g=en -g=zh"
-complete -c fabric -s u -l scrape_url -d "Scrape website URL to markdown using Jina AI"
+complete -c fabric -s u -l scrape_url -d "Scrape website URL to markdown, locally or using Jina AI"
 complete -c fabric -s q -l scrape_question -d "Search question using Jina AI"
+complete -c fabric -l scraper -a "local jina" -d "Scraper backend of --scrape_url"
 complete -c fabric -s e -l seed -d "Seed to be used for LMM generation"
 complete -c fabric -s w -l wipecontext -d "Wipe context" -a "(__fabric_get_contexts)"
 complete -c fabric -s W -l wipesession -d "Wipe session" -a "(__fabric_get_sessions)"
 complete -c fabric -l printcontext -d "Print context" -a "(__fabric_get_contexts)"
+complete -c fabric -l context-top-k -d "Number of chunks retrieved from a document context"
+complete -c fabric -l index-context -a "(__fabric_get_contexts)" -d "Index the documents of a document context with the default embedding model"
 complete -c fabric -l printsession -d "Print session" -a "(__fabric_get_sessions)"
 complete -c fabric -l address -d "The address to bind the REST API (default: :8080)"
 complete -c fabric -l api-key -d "API key used to secure server routes"
 complete -c fabric -l config -d "Path to YAML config file" -r -a "*.yaml *.yml"
-complete -c fabric -l addextension -d "Register a new extension from config file path" -r -a "*.yaml *.yml"
+complete -c fabric -l addextension -d "Register a new extension from a config file path, or install it from a directory or git URL" -r
+complete -c fabric -l update-extension -d "Update an installed extension from its directory or git repository" -a "(__fabric_get_extensions)"
 complete -c fabric -l rmextension -d "Remove a registered extension by name" -a "(__fabric_get_extensions)"
 complete -c fabric -l strategy -d "Choose a strategy from the available strategies" -a "(__fabric_get_strategies)"
 
@@ -78,9 +82,12 @@ complete -c fabric -l output-session -d "Output the entire session to the output
 complete -c fabric -s d -l changeDefaultModel -d "Change default model"
 complete -c fabric -l playlist -d "Prefer playlist over video if both ids are present in the URL"
 complete -c fabric -l transcript -d "Grab transcript from YouTube video and send to chat"
-complete -c fabric -l transcript-with-timestamps -d "Grab transcript from YouTube video with timestamps"
+complete -c fabric -l transcript-with-timestamps -d "Grab transcript from YouTube video, subtitles or transcription with timestamps"
 complete -c fabric -l comments -d "Grab comments from YouTube video and send to chat"
 complete -c fabric -l metadata -d "Output video metadata"
+complete -c fabric -l subtitles -r -d "SRT, WebVTT or YouTube XML transcript file"
+complete -c fabric -l transcribe -r -d "Audio or video file to transcribe"
+complete -c fabric -l transcribe-model -a "(__fabric_get_models)" -d "Transcription model"
 complete -c fabric -l readability -d "Convert HTML input into a clean, readable view"
 complete -c fabric -l input-has-vars -d "Apply variables to user input"
 complete -c fabric -l dry-run -d "Show what would be sent to the model without actually sending it"
@@ -90,5 +97,11 @@ complete -c fabric -l version -d "Print current version"
 complete -c fabric -l listextensions -d "List all registered extensions"
 complete -c fabric -l liststrategies -d "List all strategies"
 complete -c fabric -l listvendors -d "List all vendors"
+complete -c fabric -l list-template-plugins -d "List all template plugin namespaces and their operations"
+complete -c fabric -l suggest -d "Suggest the patterns best matching the input or question"
+complete -c fabric -l suggest-count -d "Number of patterns to suggest"
+complete -c fabric -l suggest-run -d "Suggest patterns and run the best one with the input"
+complete -c fabric -l embed -d "Output embeddings of the input and --embed-file files as JSON"
+complete -c fabric -l embed-file -r -d "File to embed with --embed, can be repeated"
 complete -c fabric -l shell-complete-list -d "Output raw list without headers/formatting (for shell completion)"
 complete -c fabric -s h -l help -d "Show this help message"
@@ -28,6 +28,9 @@ type Chatter struct {
 	modelContextLength int
 	vendor             ai.Vendor
 	strategy           string
+
+	// getEmbedder returns the embedding model for document contexts
+	getEmbedder func() (ai.Embedder, string, error)
 }
 
 // Send processes a chat request and applies any file changes if using the create_coding_feature pattern
@@ -60,15 +63,29 @@ func (o *Chatter) Send(request *common.ChatRequest, opts *common.ChatOptions) (s
 
 	if o.Stream {
 		channel := make(chan string)
+		errChannel := make(chan error, 1)
 		go func() {
-			if streamErr := o.vendor.SendStream(session.GetVendorMessages(), opts, channel); streamErr != nil {
-				channel <- streamErr.Error()
-			}
+			errChannel <- o.vendor.SendStream(session.GetVendorMessages(), opts, channel)
 		}()
 
-		for response := range channel {
-			message += response
-			fmt.Print(response)
+		// vendors may return an error without closing the channel, the
+		// stream ends with whichever comes first
+		for streaming := true; streaming; {
+			select {
+			case response, ok := <-channel:
+				if !ok {
+					err = <-errChannel
+					streaming = false
+					break
+				}
+				message += response
+				fmt.Print(response)
+			case err = <-errChannel:
+				streaming = false
+			}
+		}
+		if err != nil {
+			return
 		}
 	} else {
 		if message, err = o.vendor.Send(context.Background(), session.GetVendorMessages(), opts); err != nil {
@@ -131,15 +148,10 @@ func (o *Chatter) BuildSession(request *common.ChatRequest, raw bool) (session *
 		session.Append(&goopenai.ChatCompletionMessage{Role: common.ChatMessageRoleMeta, Content: request.Meta})
 	}
 
-	// if a context name is provided, retrieve it from the database
+	// if context names are provided, retrieve them from the database
 	var contextContent string
-	if request.ContextName != "" {
-		var ctx *fsdb.Context
-		if ctx, err = o.db.Contexts.Get(request.ContextName); err != nil {
-			err = fmt.Errorf("could not find context %s: %v", request.ContextName, err)
-			return
-		}
-		contextContent = ctx.Content
+	if contextContent, err = o.buildContextContent(request); err != nil {
+		return
 	}
 
 	// Process any template variables in the message content (user input)
@@ -220,3 +232,108 @@ func (o *Chatter) BuildSession(request *common.ChatRequest, raw bool) (session *
 	}
 	return
 }
+
+// buildContextContent joins the contexts of the request in order. Template
+// contexts are applied with the pattern variables, resolved like those of
+// patterns, and indexed document contexts contribute the chunks relevant to
+// the user input. Other contexts are sent as they are.
+func (o *Chatter) buildContextContent(request *common.ChatRequest) (ret string, err error) {
+	var input string
+	if request.Message != nil {
+		input = request.Message.Content
+	}
+
+	var parts []string
+	for _, name := range request.ContextNames {
+		var ctx *fsdb.Context
+		if ctx, err = o.db.Contexts.Get(name); err != nil {
+			err = fmt.Errorf("could not find context %s: %v", name, err)
+			return
+		}
+
+		content := ctx.Content
+		if ctx.Indexed {
+			if content, err = o.retrieveDocuments(request, name); err != nil {
+				return
+			}
+		} else if ctx.Template {
+			if content, err = o.applyContextTemplate(ctx, request.PatternVariables, input); err != nil {
+				err = fmt.Errorf("could not apply context %s: %v", name, err)
+				return
+			}
+		}
+
+		if content = strings.TrimSpace(content); content != "" {
+			parts = append(parts, content)
+		}
+	}
+	ret = strings.Join(parts, "\n\n")
+	return
+}
+
+// applyContextTemplate resolves the variables of a template context the same
+// way as those of patterns and applies them
+func (o *Chatter) applyContextTemplate(ctx *fsdb.Context, values map[string]string, input string) (ret string, err error) {
+	var variables map[string]string
+	if variables, err = template.ResolveVariables(
+		ctx.Content, ctx.Variables, values, o.db.Patterns.VariablePrompter); err != nil {
+		return
+	}
+	ret, err = template.ApplyTemplate(ctx.Content, variables, input)
+	return
+}
+
+// retrieveDocuments returns the chunks of a document context relevant to the
+// user input, in place of the whole context
+func (o *Chatter) retrieveDocuments(request *common.ChatRequest, name string) (ret string, err error) {
+	var query string
+	if request.Message != nil {
+		query = request.Message.Content
+		for _, part := range request.Message.MultiContent {
+			if part.Type == goopenai.ChatMessagePartTypeText {
+				query = strings.TrimSpace(query + "\n" + part.Text)
+			}
+		}
+	}
+	if strings.TrimSpace(query) == "" {
+		err = fmt.Errorf("document context %s needs user input to search for", name)
+		return
+	}
+
+	if o.getEmbedder == nil {
+		err = fmt.Errorf("document context %s needs an embedding model", name)
+		return
+	}
+	var embedder ai.Embedder
+	var model string
+	if embedder, model, err = o.getEmbedder(); err != nil {
+		return
+	}
+
+	topK := request.ContextTopK
+	if topK <= 0 {
+		topK = fsdb.DefaultDocumentTopK
+	}
+
+	var chunks []*fsdb.RetrievedChunk
+	if chunks, err = o.db.Contexts.Retrieve(context.Background(), name, query, topK, model,
+		func(ctx context.Context, inputs []string) ([][]float32, error) {
+			return embedder.Embed(ctx, inputs, model)
+		}); err != nil {
+		err = fmt.Errorf("could not search context %s: %v", name, err)
+		return
+	}
+
+	if len(chunks) == 0 {
+		return
+	}
+
+	var builder strings.Builder
+	builder.WriteString("Use the following excerpts of the reference documents where they are relevant:\n")
+	for _, chunk := range chunks {
+		fmt.Fprintf(&builder, "\n[%s]\n%s\n", chunk.Source, chunk.Text)
+	}
+	builder.WriteString("\n")
+	ret = builder.String()
+	return
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goopenai "github.com/sashabaranov/go-openai"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/plugins/ai"
+	"github.com/danielmiessler/fabric/plugins/ai/dryrun"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+type lengthEmbedder struct{}
+
+func (o *lengthEmbedder) Embed(_ context.Context, inputs []string, _ string) (ret [][]float32, err error) {
+	for _, input := range inputs {
+		ret = append(ret, []float32{float32(strings.Count(input, "go")), 1})
+	}
+	return
+}
+
+// failingVendor returns an error from SendStream without closing the channel
+type failingVendor struct {
+	*dryrun.Client
+}
+
+func (o *failingVendor) SendStream(_ []*goopenai.ChatCompletionMessage, _ *common.ChatOptions, channel chan string) error {
+	channel <- "partial"
+	return errors.New("invalid API key")
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildSessionWithStackedContexts(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	writeTestFile(t, filepath.Join(db.Contexts.Dir, "team"),
+		"---\nvariables:\n  - name: team\n  - name: lead\n    default: ann\n---\nTeam {{team}} {{lead}} {{plugin:text:upper:rocks}}")
+	writeTestFile(t, filepath.Join(db.Contexts.Dir, "snippets"), "Use {{#each items}}{{name}}{{/each}} and {{ alone")
+	writeTestFile(t, filepath.Join(db.Contexts.Dir, "docs", "b.md"), "second")
+	writeTestFile(t, filepath.Join(db.Contexts.Dir, "docs", "a.md"), "first\n")
+
+	chatter := &Chatter{db: db}
+	session, err := chatter.BuildSession(&common.ChatRequest{
+		ContextNames:     []string{"team", "snippets", "docs"},
+		PatternVariables: map[string]string{"team": "blue"},
+		Message:          &goopenai.ChatCompletionMessage{Role: goopenai.ChatMessageRoleUser, Content: "hi"},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Team blue ann ROCKS\n\nUse {{#each items}}{{name}}{{/each}} and {{ alone\n\n## File: a.md\n\nfirst\n\n## File: b.md\n\nsecond"
+	if system := session.Messages[0].Content; system != want {
+		t.Errorf("system message = %q, want %q", system, want)
+	}
+
+	if _, err = chatter.BuildSession(&common.ChatRequest{ContextNames: []string{"team"}}, false); err == nil {
+		t.Error("expected an error for the missing variable")
+	}
+}
+
+func TestBuildSessionWithDocumentContext(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	docs := filepath.Join(db.Contexts.Dir, "handbook")
+	writeTestFile(t, filepath.Join(docs, "go.md"), "go go go")
+	writeTestFile(t, filepath.Join(docs, "other.md"), "nothing here")
+
+	embedder := &lengthEmbedder{}
+	embed := func(ctx context.Context, inputs []string) ([][]float32, error) {
+		return embedder.Embed(ctx, inputs, "test")
+	}
+	if _, _, err := db.Contexts.IndexDocuments(context.Background(), "handbook", "test", embed); err != nil {
+		t.Fatal(err)
+	}
+
+	chatter := &Chatter{db: db, getEmbedder: func() (ai.Embedder, string, error) { return embedder, "test", nil }}
+	session, err := chatter.BuildSession(&common.ChatRequest{
+		ContextNames: []string{"handbook"},
+		ContextTopK:  1,
+		Message:      &goopenai.ChatCompletionMessage{Role: goopenai.ChatMessageRoleUser, Content: "how do I go?"},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	system := session.Messages[0].Content
+	if !strings.Contains(system, "[go.md]\ngo go go") || strings.Contains(system, "nothing here") {
+		t.Errorf("unexpected system message %q", system)
+	}
+
+	if _, err = chatter.BuildSession(&common.ChatRequest{ContextNames: []string{"handbook"}}, false); err == nil {
+		t.Error("expected an error without user input")
+	}
+}
+
+func TestSendStreamError(t *testing.T) {
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: &failingVendor{dryrun.NewClient()}, Stream: true}
+	_, err := chatter.Send(&common.ChatRequest{
+		Message: &goopenai.ChatCompletionMessage{Role: goopenai.ChatMessageRoleUser, Content: "hi"},
+	}, &common.ChatOptions{Model: "test"})
+	if err == nil || err.Error() != "invalid API key" {
+		t.Errorf("Send() error = %v, want invalid API key", err)
+	}
+}
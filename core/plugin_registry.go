@@ -21,6 +21,7 @@ import (
 	"github.com/danielmiessler/fabric/plugins/ai/anthropic"
 	"github.com/danielmiessler/fabric/plugins/ai/azure"
 	"github.com/danielmiessler/fabric/plugins/ai/dryrun"
+	"github.com/danielmiessler/fabric/plugins/ai/external"
 	"github.com/danielmiessler/fabric/plugins/ai/gemini"
 	"github.com/danielmiessler/fabric/plugins/ai/lmstudio"
 	"github.com/danielmiessler/fabric/plugins/ai/ollama"
@@ -31,6 +32,7 @@ import (
 	"github.com/danielmiessler/fabric/plugins/tools"
 	"github.com/danielmiessler/fabric/plugins/tools/jina"
 	"github.com/danielmiessler/fabric/plugins/tools/lang"
+	"github.com/danielmiessler/fabric/plugins/tools/scraper"
 	"github.com/danielmiessler/fabric/plugins/tools/youtube"
 )
 
@@ -43,6 +45,7 @@ func NewPluginRegistry(db *fsdb.Db) (ret *PluginRegistry, err error) {
 		YouTube:        youtube.NewYouTube(),
 		Language:       lang.NewLanguage(),
 		Jina:           jina.NewClient(),
+		Scraper:        scraper.NewClient(),
 		Strategies:     strategy.NewStrategiesManager(),
 	}
 
@@ -74,6 +77,24 @@ func NewPluginRegistry(db *fsdb.Db) (ret *PluginRegistry, err error) {
 		vendors = append(vendors, openai_compatible.NewClient(provider))
 	}
 
+	// Add OpenAI-compatible providers defined by the user
+	userProviders, providersErr := openai_compatible.LoadProviders(filepath.Join(homedir, ".config/fabric", openai_compatible.ProvidersFileName))
+	if providersErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load providers: %v\n", providersErr)
+	}
+	for _, provider := range userProviders {
+		vendors = addVendorUnlessExists(vendors, openai_compatible.NewClient(provider))
+	}
+
+	// Add vendors implemented by external executables, see plugins/ai/external
+	externalVendors, externalErr := external.LoadVendors(filepath.Join(homedir, ".config/fabric", external.ConfigFileName))
+	if externalErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load external vendors: %v\n", externalErr)
+	}
+	for _, vendor := range externalVendors {
+		vendors = addVendorUnlessExists(vendors, vendor)
+	}
+
 	// Sort vendors by name for consistent ordering (case-insensitive)
 	sort.Slice(vendors, func(i, j int) bool {
 		return strings.ToLower(vendors[i].GetName()) < strings.ToLower(vendors[j].GetName())
@@ -86,6 +107,18 @@ func NewPluginRegistry(db *fsdb.Db) (ret *PluginRegistry, err error) {
 	return
 }
 
+// addVendorUnlessExists adds a user-defined vendor, which must not replace
+// a vendor of the same name
+func addVendorUnlessExists(vendors []ai.Vendor, vendor ai.Vendor) []ai.Vendor {
+	if lo.ContainsBy(vendors, func(existing ai.Vendor) bool {
+		return strings.EqualFold(existing.GetName(), vendor.GetName())
+	}) {
+		fmt.Fprintf(os.Stderr, "Warning: vendor %s ignored, a vendor with this name exists\n", vendor.GetName())
+		return vendors
+	}
+	return append(vendors, vendor)
+}
+
 func (o *PluginRegistry) ListVendors(out io.Writer) error {
 	vendors := lo.Map(o.VendorsAll.Vendors, func(vendor ai.Vendor, _ int) string {
 		return vendor.GetName()
@@ -107,6 +140,7 @@ type PluginRegistry struct {
 	YouTube            *youtube.YouTube
 	Language           *lang.Language
 	Jina               *jina.Client
+	Scraper            *scraper.Client
 	TemplateExtensions *template.ExtensionManager
 	Strategies         *strategy.StrategiesManager
 }
@@ -125,6 +159,7 @@ func (o *PluginRegistry) SaveEnvFile() (err error) {
 
 	o.YouTube.SetupFillEnvFileContent(&envFileContent)
 	o.Jina.SetupFillEnvFileContent(&envFileContent)
+	o.Scraper.SetupFillEnvFileContent(&envFileContent)
 	o.Language.SetupFillEnvFileContent(&envFileContent)
 
 	err = o.Db.SaveEnv(envFileContent.String())
@@ -149,7 +184,7 @@ func (o *PluginRegistry) Setup() (err error) {
 			return vendor
 		})...)
 
-	groupsPlugins.AddGroupItems("Tools", o.Defaults, o.Jina, o.Language, o.PatternsLoader, o.Strategies, o.YouTube)
+	groupsPlugins.AddGroupItems("Tools", o.Defaults, o.Jina, o.Language, o.PatternsLoader, o.Strategies, o.Scraper, o.YouTube)
 
 	for {
 		groupsPlugins.Print(false)
@@ -223,9 +258,10 @@ func (o *PluginRegistry) Configure() (err error) {
 	_ = o.Defaults.Configure()
 	_ = o.PatternsLoader.Configure()
 
-	//YouTube and Jina are not mandatory, so ignore not configured error
+	//YouTube, Jina and the scraper are not mandatory, so ignore not configured error
 	_ = o.YouTube.Configure()
 	_ = o.Jina.Configure()
+	_ = o.Scraper.Configure()
 	_ = o.Language.Configure()
 	return
 }
@@ -283,5 +319,79 @@ func (o *PluginRegistry) GetChatter(model string, modelContextLength int, strate
 		return
 	}
 	ret.strategy = strategy
+	ret.getEmbedder = func() (ai.Embedder, string, error) { return o.GetEmbedder("") }
+	return
+}
+
+// GetEmbedder returns the configured vendor providing the embedding model,
+// the default embedding model is used when model is empty
+func (o *PluginRegistry) GetEmbedder(model string) (ret ai.Embedder, retModel string, err error) {
+	if retModel = model; retModel == "" {
+		if retModel = o.Defaults.EmbeddingModel.Value; retModel == "" {
+			err = fmt.Errorf("no embedding model, choose one with --model or set a default with fabric --setup")
+			return
+		}
+	}
+
+	var models *ai.VendorsModels
+	if models, err = o.VendorManager.GetModels(); err != nil {
+		return
+	}
+
+	for _, vendorName := range models.FindGroupsByItem(retModel) {
+		if embedder, ok := o.VendorManager.FindByName(vendorName).(ai.Embedder); ok {
+			ret = embedder
+			return
+		}
+	}
+	err = fmt.Errorf("could not find a vendor providing embeddings with model %s", retModel)
+	return
+}
+
+// GetTranscriber returns the configured vendor providing the transcription
+// model, the default transcription model is used when model is empty
+func (o *PluginRegistry) GetTranscriber(model string) (ret ai.Transcriber, retModel string, err error) {
+	if retModel = model; retModel == "" {
+		if retModel = o.Defaults.TranscriptionModel.Value; retModel == "" {
+			err = fmt.Errorf("no transcription model, choose one with --transcribe-model or set a default with fabric --setup")
+			return
+		}
+	}
+
+	var models *ai.VendorsModels
+	if models, err = o.VendorManager.GetModels(); err != nil {
+		return
+	}
+
+	for _, vendorName := range models.FindGroupsByItem(retModel) {
+		if transcriber, ok := o.VendorManager.FindByName(vendorName).(ai.Transcriber); ok {
+			ret = transcriber
+			return
+		}
+	}
+	err = fmt.Errorf("could not find a vendor providing transcriptions with model %s", retModel)
+	return
+}
+
+// GetScraper returns the scraper of the backend, local or jina. Without a
+// backend the default backend is used, or Jina AI when only its API key is set.
+func (o *PluginRegistry) GetScraper(backend string) (ret scraper.Scraper, err error) {
+	if backend == "" {
+		if backend = o.Scraper.DefaultBackend.Value; backend == "" {
+			backend = scraper.BackendLocal
+			if o.Jina.ApiKey.Value != "" {
+				backend = scraper.BackendJina
+			}
+		}
+	}
+
+	switch backend {
+	case scraper.BackendLocal:
+		ret = o.Scraper
+	case scraper.BackendJina:
+		ret = o.Jina
+	default:
+		err = fmt.Errorf("unknown scraper backend %s, use %s or %s", backend, scraper.BackendLocal, scraper.BackendJina)
+	}
 	return
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/scraper"
 )
 
 func TestSaveEnvFile(t *testing.T) {
@@ -19,3 +20,47 @@ func TestSaveEnvFile(t *testing.T) {
 		t.Fatalf("SaveEnvFile() error = %v", err)
 	}
 }
+
+func TestGetScraper(t *testing.T) {
+	db := fsdb.NewDb(os.TempDir())
+	registry, err := NewPluginRegistry(db)
+	if err != nil {
+		t.Fatalf("NewPluginRegistry() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		backend        string
+		defaultBackend string
+		jinaApiKey     string
+		expected       scraper.Scraper
+		err            bool
+	}{
+		{name: "local without Jina API key", expected: registry.Scraper},
+		{name: "Jina with API key", jinaApiKey: "key", expected: registry.Jina},
+		{name: "default backend", defaultBackend: scraper.BackendLocal, jinaApiKey: "key", expected: registry.Scraper},
+		{name: "backend of the call", backend: scraper.BackendJina, defaultBackend: scraper.BackendLocal, expected: registry.Jina},
+		{name: "unknown backend", backend: "curl", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry.Scraper.DefaultBackend.Value = tt.defaultBackend
+			registry.Jina.ApiKey.Value = tt.jinaApiKey
+
+			got, err := registry.GetScraper(tt.backend)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("GetScraper() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetScraper() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("GetScraper() = %T, want %T", got, tt.expected)
+			}
+		})
+	}
+}
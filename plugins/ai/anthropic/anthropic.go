@@ -3,7 +3,10 @@ package anthropic
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
@@ -14,6 +17,13 @@ import (
 
 const defaultBaseUrl = "https://api.anthropic.com/"
 
+// defaultMaxTokens is used unless ANTHROPIC_MAX_TOKENS is set
+const defaultMaxTokens = 4096
+
+// listModelsTimeout bounds the Models API request before falling back to
+// the static model list
+const listModelsTimeout = 10 * time.Second
+
 func NewClient() (ret *Client) {
 	vendorName := "Anthropic"
 	ret = &Client{}
@@ -27,8 +37,10 @@ func NewClient() (ret *Client) {
 	ret.ApiBaseURL = ret.AddSetupQuestion("API Base URL", false)
 	ret.ApiBaseURL.Value = defaultBaseUrl
 	ret.ApiKey = ret.PluginBase.AddSetupQuestion("API key", true)
+	ret.MaxTokens = ret.AddSetupQuestionCustom("Max Tokens", false,
+		fmt.Sprintf("Enter the maximum number of tokens of an answer (leave empty for %d)", defaultMaxTokens))
 
-	ret.maxTokens = 4096
+	ret.maxTokens = defaultMaxTokens
 	ret.defaultRequiredUserMessage = "Hi"
 	ret.models = []string{
 		anthropic.ModelClaude3_7SonnetLatest, anthropic.ModelClaude3_7Sonnet20250219,
@@ -47,6 +59,7 @@ type Client struct {
 	*plugins.PluginBase
 	ApiBaseURL *plugins.SetupQuestion
 	ApiKey     *plugins.SetupQuestion
+	MaxTokens  *plugins.SetupQuestion
 
 	maxTokens                  int
 	defaultRequiredUserMessage string
@@ -56,6 +69,13 @@ type Client struct {
 }
 
 func (an *Client) configure() (err error) {
+	an.maxTokens = defaultMaxTokens
+	if an.MaxTokens.Value != "" {
+		if an.maxTokens, err = strconv.Atoi(an.MaxTokens.Value); err != nil || an.maxTokens <= 0 {
+			return fmt.Errorf("invalid %s %q: must be a positive number", an.MaxTokens.EnvVariable, an.MaxTokens.Value)
+		}
+	}
+
 	if an.ApiBaseURL.Value != "" {
 		baseURL := an.ApiBaseURL.Value
 
@@ -76,23 +96,32 @@ func (an *Client) configure() (err error) {
 	return
 }
 
+// ListModels asks the Models API and falls back to the static list without
+// an API key or when the request fails
 func (an *Client) ListModels() (ret []string, err error) {
-	return an.models, nil
+	if an.ApiKey.Value == "" {
+		return an.models, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), listModelsTimeout)
+	defer cancel()
+
+	pager := an.client.Models.ListAutoPaging(ctx, anthropic.ModelListParams{})
+	for pager.Next() {
+		ret = append(ret, pager.Current().ID)
+	}
+	if pagerErr := pager.Err(); pagerErr != nil || len(ret) == 0 {
+		slog.Debug("Anthropic models API unavailable, using static model list", "error", pagerErr)
+		return an.models, nil
+	}
+	return
 }
 
 func (an *Client) SendStream(
 	msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions, channel chan string,
 ) (err error) {
-	messages := an.toMessages(msgs)
-
 	ctx := context.Background()
-	stream := an.client.Messages.NewStreaming(ctx, anthropic.MessageNewParams{
-		Model:       opts.Model,
-		MaxTokens:   int64(an.maxTokens),
-		TopP:        anthropic.Opt(opts.TopP),
-		Temperature: anthropic.Opt(opts.Temperature),
-		Messages:    messages,
-	})
+	stream := an.client.Messages.NewStreaming(ctx, an.buildMessageParams(msgs, opts))
 
 	for stream.Next() {
 		event := stream.Current()
@@ -111,34 +140,84 @@ func (an *Client) SendStream(
 }
 
 func (an *Client) Send(ctx context.Context, msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions) (ret string, err error) {
-	messages := an.toMessages(msgs)
-
 	var message *anthropic.Message
-	if message, err = an.client.Messages.New(ctx, anthropic.MessageNewParams{
+	if message, err = an.client.Messages.New(ctx, an.buildMessageParams(msgs, opts)); err != nil {
+		return
+	}
+	for _, block := range message.Content {
+		ret += block.Text
+	}
+	return
+}
+
+func (an *Client) buildMessageParams(msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions) anthropic.MessageNewParams {
+	system, messages := an.toMessages(msgs)
+	return anthropic.MessageNewParams{
 		Model:       opts.Model,
 		MaxTokens:   int64(an.maxTokens),
 		TopP:        anthropic.Opt(opts.TopP),
 		Temperature: anthropic.Opt(opts.Temperature),
+		System:      system,
 		Messages:    messages,
-	}); err != nil {
-		return
 	}
-	ret = message.Content[0].Text
-	return
 }
 
-func (an *Client) toMessages(msgs []*goopenai.ChatCompletionMessage) (ret []anthropic.MessageParam) {
-	normalizedMessages := common.NormalizeMessages(msgs, an.defaultRequiredUserMessage)
+// toMessages sends system messages, such as the pattern, in the system field
+// of the API and converts image attachments to image blocks
+func (an *Client) toMessages(msgs []*goopenai.ChatCompletionMessage) (system []anthropic.TextBlockParam, ret []anthropic.MessageParam) {
+	var conversation []*goopenai.ChatCompletionMessage
+	for _, msg := range msgs {
+		if msg.Role == goopenai.ChatMessageRoleSystem {
+			if msg.Content != "" {
+				system = append(system, anthropic.TextBlockParam{Text: msg.Content})
+			}
+			continue
+		}
+		conversation = append(conversation, msg)
+	}
+
+	normalizedMessages := common.NormalizeMessages(conversation, an.defaultRequiredUserMessage)
 
 	for _, msg := range normalizedMessages {
 		var message anthropic.MessageParam
 		switch msg.Role {
 		case goopenai.ChatMessageRoleUser:
-			message = anthropic.NewUserMessage(anthropic.NewTextBlock(msg.Content))
+			message = anthropic.NewUserMessage(toContentBlocks(msg)...)
 		default:
-			message = anthropic.NewAssistantMessage(anthropic.NewTextBlock(msg.Content))
+			message = anthropic.NewAssistantMessage(toContentBlocks(msg)...)
 		}
 		ret = append(ret, message)
 	}
 	return
 }
+
+func toContentBlocks(msg *goopenai.ChatCompletionMessage) (ret []anthropic.ContentBlockParamUnion) {
+	if len(msg.MultiContent) == 0 {
+		return []anthropic.ContentBlockParamUnion{anthropic.NewTextBlock(msg.Content)}
+	}
+	for _, part := range msg.MultiContent {
+		switch part.Type {
+		case goopenai.ChatMessagePartTypeText:
+			ret = append(ret, anthropic.NewTextBlock(part.Text))
+		case goopenai.ChatMessagePartTypeImageURL:
+			if part.ImageURL != nil {
+				ret = append(ret, toImageBlock(part.ImageURL.URL))
+			}
+		}
+	}
+	return
+}
+
+// toImageBlock embeds data URLs and passes other URLs for Anthropic to fetch
+func toImageBlock(url string) anthropic.ContentBlockParamUnion {
+	if header, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ";base64,"); ok && strings.HasPrefix(url, "data:") {
+		return anthropic.NewImageBlockBase64(header, data)
+	}
+	return anthropic.ContentBlockParamUnion{
+		OfRequestImageBlock: &anthropic.ImageBlockParam{
+			Source: anthropic.ImageBlockParamSourceUnion{
+				OfURLImageSource: &anthropic.URLImageSourceParam{URL: url},
+			},
+		},
+	}
+}
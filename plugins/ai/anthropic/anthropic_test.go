@@ -1,7 +1,13 @@
 package anthropic
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	goopenai "github.com/sashabaranov/go-openai"
 )
 
 // Test generated using Keploy
@@ -63,3 +69,103 @@ func TestClient_ListModels_ReturnsCorrectModels(t *testing.T) {
 		}
 	}
 }
+
+func TestToMessages(t *testing.T) {
+	client := NewClient()
+
+	msgs := []*goopenai.ChatCompletionMessage{
+		{Role: goopenai.ChatMessageRoleSystem, Content: "You are a pattern"},
+		{Role: goopenai.ChatMessageRoleUser, MultiContent: []goopenai.ChatMessagePart{
+			{Type: goopenai.ChatMessagePartTypeText, Text: "Describe"},
+			{Type: goopenai.ChatMessagePartTypeImageURL, ImageURL: &goopenai.ChatMessageImageURL{URL: "data:image/png;base64,AAAA"}},
+			{Type: goopenai.ChatMessagePartTypeImageURL, ImageURL: &goopenai.ChatMessageImageURL{URL: "https://example.com/cat.jpg"}},
+		}},
+		{Role: goopenai.ChatMessageRoleAssistant, Content: "A cat"},
+	}
+
+	system, messages := client.toMessages(msgs)
+	if len(system) != 1 || system[0].Text != "You are a pattern" {
+		t.Errorf("Expected the system message in the system field, got %+v", system)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Role != anthropic.MessageParamRoleUser || messages[1].Role != anthropic.MessageParamRoleAssistant {
+		t.Errorf("Expected user and assistant roles, got %s and %s", messages[0].Role, messages[1].Role)
+	}
+
+	blocks := messages[0].Content
+	if len(blocks) != 3 {
+		t.Fatalf("Expected 3 content blocks, got %d", len(blocks))
+	}
+	if blocks[0].OfRequestTextBlock == nil || blocks[0].OfRequestTextBlock.Text != "Describe" {
+		t.Errorf("Expected text block, got %+v", blocks[0])
+	}
+	if image := blocks[1].OfRequestImageBlock; image == nil || image.Source.OfBase64ImageSource == nil ||
+		image.Source.OfBase64ImageSource.MediaType != "image/png" || image.Source.OfBase64ImageSource.Data != "AAAA" {
+		t.Errorf("Expected base64 image block, got %+v", blocks[1])
+	}
+	if image := blocks[2].OfRequestImageBlock; image == nil || image.Source.OfURLImageSource == nil ||
+		image.Source.OfURLImageSource.URL != "https://example.com/cat.jpg" {
+		t.Errorf("Expected URL image block, got %+v", blocks[2])
+	}
+}
+
+func TestConfigureMaxTokens(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", value: "", want: defaultMaxTokens},
+		{name: "custom", value: "8192", want: 8192},
+		{name: "invalid", value: "many", wantErr: true},
+		{name: "zero", value: "0", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewClient()
+			client.MaxTokens.Value = tc.value
+			err := client.configure()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("configure() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && client.maxTokens != tc.want {
+				t.Errorf("Expected maxTokens %d, got %d", tc.want, client.maxTokens)
+			}
+		})
+	}
+}
+
+func TestListModelsFromAPI(t *testing.T) {
+	available := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available || r.URL.Path != "/v1/models" {
+			http.Error(w, `{"type":"error","error":{"type":"not_found_error","message":"not found"}}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"data": [{"id": "claude-new", "type": "model", "display_name": "New", "created_at": "2025-01-01T00:00:00Z"}], "has_more": false}`)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.ApiKey.Value = "test-key"
+	client.ApiBaseURL.Value = server.URL
+	if err := client.configure(); err != nil {
+		t.Fatal(err)
+	}
+
+	models, err := client.ListModels()
+	if err != nil || len(models) != 1 || models[0] != "claude-new" {
+		t.Errorf("Expected models from the API, got %v, %v", models, err)
+	}
+
+	available = false
+	models, err = client.ListModels()
+	if err != nil || len(models) != len(client.models) {
+		t.Errorf("Expected the static model list as fallback, got %v, %v", models, err)
+	}
+}
@@ -0,0 +1,288 @@
+package external
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	goopenai "github.com/sashabaranov/go-openai"
+	"gopkg.in/yaml.v3"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/plugins"
+)
+
+// ConfigFileName is the file in the config directory registering external vendors
+const ConfigFileName = "vendors.yaml"
+
+// DefaultTimeout bounds list_models and send calls without a timeout
+const DefaultTimeout = 5 * time.Minute
+
+// maxMessageSize is the longest line accepted from an executable
+const maxMessageSize = 16 * 1024 * 1024
+
+// Config is the content of vendors.yaml:
+//
+//	vendors:
+//	  - name: MyVendor
+//	    command: ~/bin/my-vendor
+//	    args: ["--serve-stdio"]
+//	    timeout: 2m
+//	    settings:
+//	      - name: API Key
+//	        required: true
+type Config struct {
+	Vendors []VendorConfig `yaml:"vendors"`
+}
+
+// VendorConfig registers one executable implementing the protocol
+type VendorConfig struct {
+	Name    string   `yaml:"name"`
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+	Timeout string   `yaml:"timeout"`
+	// Settings are asked during setup, stored in .env as
+	// <NAME>_<SETTING> and passed to the executable as environment variables
+	Settings []SettingConfig `yaml:"settings"`
+}
+
+type SettingConfig struct {
+	Name     string `yaml:"name"`
+	Required bool   `yaml:"required"`
+}
+
+// LoadVendors creates clients for the vendors registered in configPath.
+// A missing file registers no vendors.
+func LoadVendors(configPath string) (ret []*Client, err error) {
+	var data []byte
+	if data, err = os.ReadFile(configPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+
+	var config Config
+	if err = yaml.Unmarshal(data, &config); err != nil {
+		err = fmt.Errorf("failed to parse %s: %w", configPath, err)
+		return
+	}
+
+	for _, vendor := range config.Vendors {
+		if vendor.Name == "" || vendor.Command == "" {
+			err = fmt.Errorf("%s: vendors need a name and a command", configPath)
+			return
+		}
+		if vendor.Timeout != "" {
+			if _, parseErr := time.ParseDuration(vendor.Timeout); parseErr != nil {
+				err = fmt.Errorf("%s: invalid timeout of vendor %s: %w", configPath, vendor.Name, parseErr)
+				return
+			}
+		}
+		vendor.Command = resolveCommand(vendor.Command, filepath.Dir(configPath))
+		ret = append(ret, NewClient(vendor))
+	}
+	return
+}
+
+// resolveCommand expands ~ and makes relative paths relative to dir, while
+// plain command names are looked up in PATH
+func resolveCommand(command string, dir string) string {
+	if strings.HasPrefix(command, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, command[2:])
+		}
+	}
+	if !filepath.IsAbs(command) && strings.ContainsRune(command, filepath.Separator) {
+		return filepath.Join(dir, command)
+	}
+	return command
+}
+
+func NewClient(config VendorConfig) (ret *Client) {
+	ret = &Client{Config: config}
+	ret.PluginBase = &plugins.PluginBase{
+		Name:             config.Name,
+		SetupDescription: config.Name + " (external)",
+		EnvNamePrefix:    plugins.BuildEnvVariablePrefix(config.Name),
+		ConfigureCustom:  ret.configure,
+	}
+	for _, setting := range config.Settings {
+		ret.settings = append(ret.settings, ret.AddSetupQuestion(setting.Name, setting.Required))
+	}
+	return
+}
+
+// Client is an ai.Vendor implemented by an external executable
+type Client struct {
+	*plugins.PluginBase
+	Config   VendorConfig
+	settings []*plugins.SetupQuestion
+}
+
+func (o *Client) configure() (err error) {
+	if _, err = exec.LookPath(o.Config.Command); err != nil {
+		err = fmt.Errorf("%s: %w", o.Name, err)
+	}
+	return
+}
+
+func (o *Client) ListModels() (ret []string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), o.timeout())
+	defer cancel()
+
+	var result ListModelsResult
+	if err = o.call(ctx, MethodListModels, nil, nil, &result); err != nil {
+		return
+	}
+	ret = result.Models
+	return
+}
+
+func (o *Client) Send(ctx context.Context, msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions) (ret string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, o.timeout())
+	defer cancel()
+
+	var result SendResult
+	params := SendParams{Messages: msgs, Options: NewOptions(opts)}
+	if err = o.call(ctx, MethodSend, params, nil, &result); err != nil {
+		return
+	}
+	ret = result.Content
+	return
+}
+
+// SendStream has no timeout as answers may take arbitrarily long, the
+// executable ends the stream with its response
+func (o *Client) SendStream(msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions, channel chan string) (err error) {
+	defer close(channel)
+
+	var result SendResult
+	params := SendParams{Messages: msgs, Options: NewOptions(opts)}
+	onChunk := func(content string) {
+		channel <- content
+	}
+	if err = o.call(context.Background(), MethodSendStream, params, onChunk, &result); err != nil {
+		return
+	}
+	if result.Content != "" {
+		channel <- result.Content
+	}
+	return
+}
+
+func (o *Client) timeout() time.Duration {
+	if timeout, err := time.ParseDuration(o.Config.Timeout); err == nil {
+		return timeout
+	}
+	return DefaultTimeout
+}
+
+// env passes the settings of the vendor in addition to fabric's environment
+func (o *Client) env() (ret []string) {
+	ret = os.Environ()
+	for _, setting := range o.settings {
+		if setting.Value != "" {
+			ret = append(ret, setting.EnvVariable+"="+setting.Value)
+		}
+	}
+	return
+}
+
+// call runs the executable for one request, passing notifications to
+// onChunk and decoding the result into result
+func (o *Client) call(ctx context.Context, method string, params any, onChunk func(string), result any) (err error) {
+	// every call has its own process, so one id is enough
+	request := Request{JSONRPC: JSONRPCVersion, ID: 1, Method: method, Params: params}
+	var requestLine []byte
+	if requestLine, err = json.Marshal(request); err != nil {
+		return
+	}
+
+	cmd := exec.CommandContext(ctx, o.Config.Command, o.Config.Args...)
+	cmd.Env = o.env()
+	cmd.Stdin = bytes.NewReader(append(requestLine, '\n'))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return
+	}
+	if err = cmd.Start(); err != nil {
+		return fmt.Errorf("%s: failed to start %s: %w", o.Name, o.Config.Command, err)
+	}
+
+	response, readErr := o.readResponse(stdout, request.ID, onChunk)
+	if readErr != nil {
+		_ = cmd.Process.Kill()
+	} else {
+		// the executable must not block on output nobody reads
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	waitErr := cmd.Wait()
+
+	if readErr == nil && response == nil {
+		readErr = fmt.Errorf("no response to %s", method)
+	}
+	if readErr == nil && response.Error != nil {
+		readErr = response.Error
+	}
+	if readErr == nil && waitErr != nil {
+		readErr = waitErr
+	}
+	if readErr != nil {
+		if ctx.Err() != nil {
+			readErr = ctx.Err()
+		}
+		err = fmt.Errorf("%s: %w", o.Name, readErr)
+		if details := strings.TrimSpace(stderr.String()); details != "" {
+			err = fmt.Errorf("%w\nstderr: %s", err, details)
+		}
+		return
+	}
+
+	if result != nil && len(response.Result) > 0 {
+		if err = json.Unmarshal(response.Result, result); err != nil {
+			err = fmt.Errorf("%s: invalid result of %s: %w", o.Name, method, err)
+		}
+	}
+	return
+}
+
+func (o *Client) readResponse(stdout io.Reader, id int, onChunk func(string)) (ret *Message, err error) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var message Message
+		if err = json.Unmarshal(line, &message); err != nil {
+			return nil, fmt.Errorf("invalid message %q: %w", line, err)
+		}
+		if message.ID == nil {
+			if message.Method == MethodChunk && onChunk != nil {
+				var chunk ChunkParams
+				if err = json.Unmarshal(message.Params, &chunk); err != nil {
+					return nil, fmt.Errorf("invalid chunk: %w", err)
+				}
+				onChunk(chunk.Content)
+			}
+			continue
+		}
+		if *message.ID == id {
+			return &message, nil
+		}
+	}
+	return nil, scanner.Err()
+}
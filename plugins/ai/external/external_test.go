@@ -0,0 +1,170 @@
+package external
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/danielmiessler/fabric/common"
+)
+
+// TestMain lets the test binary act as an external vendor when
+// FABRIC_TEST_VENDOR is set
+func TestMain(m *testing.M) {
+	if os.Getenv("FABRIC_TEST_VENDOR") == "1" {
+		runTestVendor()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func runTestVendor() {
+	var request struct {
+		ID     int        `json:"id"`
+		Method string     `json:"method"`
+		Params SendParams `json:"params"`
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Scan()
+	if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+		fmt.Fprintln(os.Stderr, "bad request:", err)
+		os.Exit(1)
+	}
+
+	write := func(message any) {
+		data, _ := json.Marshal(message)
+		fmt.Println(string(data))
+	}
+	respond := func(result any) {
+		write(map[string]any{"jsonrpc": JSONRPCVersion, "id": request.ID, "result": result})
+	}
+
+	switch request.Method {
+	case MethodListModels:
+		respond(ListModelsResult{Models: []string{"echo-1", "echo-2"}})
+	case MethodSend:
+		last := request.Params.Messages[len(request.Params.Messages)-1]
+		if last.Content == "fail" {
+			fmt.Fprintln(os.Stderr, "details for the user")
+			write(map[string]any{"jsonrpc": JSONRPCVersion, "id": request.ID,
+				"error": Error{Code: -32000, Message: "model overloaded"}})
+			return
+		}
+		respond(SendResult{Content: fmt.Sprintf("%s:%s:%s", request.Params.Options.Model, last.Content, os.Getenv("TESTVENDOR_API_KEY"))})
+	case MethodSendStream:
+		for _, word := range strings.Fields(request.Params.Messages[0].Content) {
+			write(map[string]any{"jsonrpc": JSONRPCVersion, "method": MethodChunk, "params": ChunkParams{Content: word}})
+		}
+		respond(SendResult{Content: "."})
+	default:
+		os.Exit(3)
+	}
+}
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	t.Setenv("FABRIC_TEST_VENDOR", "1")
+	client := NewClient(VendorConfig{
+		Name:     "TestVendor",
+		Command:  os.Args[0],
+		Settings: []SettingConfig{{Name: "API Key", Required: true}},
+	})
+	client.settings[0].Value = "secret"
+	require.NoError(t, client.Configure())
+	return client
+}
+
+func TestClient(t *testing.T) {
+	client := newTestClient(t)
+
+	models, err := client.ListModels()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"echo-1", "echo-2"}, models)
+
+	msgs := []*goopenai.ChatCompletionMessage{{Role: goopenai.ChatMessageRoleUser, Content: "hi"}}
+	answer, err := client.Send(context.Background(), msgs, &common.ChatOptions{Model: "echo-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "echo-1:hi:secret", answer)
+
+	_, err = client.Send(context.Background(),
+		[]*goopenai.ChatCompletionMessage{{Role: goopenai.ChatMessageRoleUser, Content: "fail"}}, &common.ChatOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "model overloaded")
+	assert.Contains(t, err.Error(), "details for the user")
+
+	channel := make(chan string)
+	go func() {
+		err := client.SendStream([]*goopenai.ChatCompletionMessage{{Role: goopenai.ChatMessageRoleUser, Content: "a b c"}},
+			&common.ChatOptions{}, channel)
+		assert.NoError(t, err)
+	}()
+	var chunks []string
+	for chunk := range channel {
+		chunks = append(chunks, chunk)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "."}, chunks)
+}
+
+func TestClientErrors(t *testing.T) {
+	client := NewClient(VendorConfig{Name: "Missing", Command: "fabric-no-such-vendor"})
+	assert.Error(t, client.Configure())
+
+	// an executable exiting without a response
+	t.Setenv("FABRIC_TEST_VENDOR", "")
+	client = NewClient(VendorConfig{Name: "Silent", Command: "true"})
+	_, err := client.ListModels()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no response")
+
+	// a failing stream still ends
+	client = NewClient(VendorConfig{Name: "Failing", Command: "false"})
+	channel := make(chan string)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- client.SendStream([]*goopenai.ChatCompletionMessage{{Role: goopenai.ChatMessageRoleUser, Content: "hi"}},
+			&common.ChatOptions{}, channel)
+	}()
+	for range channel {
+	}
+	assert.Error(t, <-errs)
+}
+
+func TestLoadVendors(t *testing.T) {
+	dir := t.TempDir()
+
+	vendors, err := LoadVendors(filepath.Join(dir, ConfigFileName))
+	require.NoError(t, err)
+	assert.Empty(t, vendors)
+
+	config := `vendors:
+  - name: Local
+    command: bin/local-vendor
+    timeout: 1m
+    settings:
+      - name: API Key
+        required: true
+      - name: Region
+`
+	configPath := filepath.Join(dir, ConfigFileName)
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0644))
+	vendors, err = LoadVendors(configPath)
+	require.NoError(t, err)
+	require.Len(t, vendors, 1)
+	assert.Equal(t, "Local", vendors[0].GetName())
+	assert.Equal(t, filepath.Join(dir, "bin", "local-vendor"), vendors[0].Config.Command)
+	assert.Equal(t, "LOCAL_API_KEY", vendors[0].settings[0].EnvVariable)
+	assert.Len(t, vendors[0].SetupQuestions, 2)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("vendors:\n  - name: NoCommand\n"), 0644))
+	_, err = LoadVendors(configPath)
+	assert.Error(t, err)
+}
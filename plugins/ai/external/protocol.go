@@ -0,0 +1,100 @@
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+
+	goopenai "github.com/sashabaranov/go-openai"
+
+	"github.com/danielmiessler/fabric/common"
+)
+
+// External vendors speak JSON-RPC 2.0 over stdio. For every call fabric
+// starts the configured executable, writes one request line to its stdin
+// and closes it. The executable writes one JSON message per line to stdout:
+// any number of chunk notifications while streaming, then the response with
+// the id of the request. Anything written to stderr is shown with errors.
+const (
+	JSONRPCVersion = "2.0"
+
+	MethodListModels = "list_models" // result: ListModelsResult
+	MethodSend       = "send"        // params: SendParams, result: SendResult
+	MethodSendStream = "send_stream" // params: SendParams, notifications: MethodChunk, result: SendResult
+
+	// MethodChunk is the notification carrying a part of a streamed answer
+	MethodChunk = "chunk"
+)
+
+// Request is the message written to the executable
+type Request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// Message is a response or a notification written by the executable
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int            `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// SendParams are the parameters of send and send_stream. Messages use the
+// OpenAI chat format, content is an array of parts for attachments.
+type SendParams struct {
+	Messages []*goopenai.ChatCompletionMessage `json:"messages"`
+	Options  Options                           `json:"options"`
+}
+
+// Options mirror common.ChatOptions
+type Options struct {
+	Model              string  `json:"model"`
+	Temperature        float64 `json:"temperature"`
+	TopP               float64 `json:"top_p"`
+	PresencePenalty    float64 `json:"presence_penalty"`
+	FrequencyPenalty   float64 `json:"frequency_penalty"`
+	Raw                bool    `json:"raw"`
+	Seed               int     `json:"seed,omitempty"`
+	ModelContextLength int     `json:"model_context_length,omitempty"`
+}
+
+func NewOptions(opts *common.ChatOptions) Options {
+	return Options{
+		Model:              opts.Model,
+		Temperature:        opts.Temperature,
+		TopP:               opts.TopP,
+		PresencePenalty:    opts.PresencePenalty,
+		FrequencyPenalty:   opts.FrequencyPenalty,
+		Raw:                opts.Raw,
+		Seed:               opts.Seed,
+		ModelContextLength: opts.ModelContextLength,
+	}
+}
+
+type ListModelsResult struct {
+	Models []string `json:"models"`
+}
+
+// SendResult is the answer of send. For send_stream content is optional
+// and is emitted after the chunks when set.
+type SendResult struct {
+	Content string `json:"content"`
+}
+
+type ChunkParams struct {
+	Content string `json:"content"`
+}
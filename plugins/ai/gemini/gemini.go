@@ -17,13 +17,19 @@ import (
 
 const modelsNamePrefix = "models/"
 
+const (
+	roleUser  = "user"
+	roleModel = "model"
+)
+
 func NewClient() (ret *Client) {
 	vendorName := "Gemini"
 	ret = &Client{}
 
 	ret.PluginBase = &plugins.PluginBase{
-		Name:          vendorName,
-		EnvNamePrefix: plugins.BuildEnvVariablePrefix(vendorName),
+		Name:            vendorName,
+		EnvNamePrefix:   plugins.BuildEnvVariablePrefix(vendorName),
+		ConfigureCustom: ret.configure,
 	}
 
 	ret.ApiKey = ret.PluginBase.AddSetupQuestion("API key", true)
@@ -34,17 +40,34 @@ func NewClient() (ret *Client) {
 type Client struct {
 	*plugins.PluginBase
 	ApiKey *plugins.SetupQuestion
+
+	client *genai.Client
+}
+
+// configure creates the genai client shared by all calls
+func (o *Client) configure() (err error) {
+	if o.client != nil {
+		o.client.Close()
+		o.client = nil
+	}
+	o.client, err = genai.NewClient(context.Background(), option.WithAPIKey(o.ApiKey.Value))
+	return
+}
+
+func (o *Client) getClient() (ret *genai.Client, err error) {
+	if ret = o.client; ret == nil {
+		err = fmt.Errorf("%s is not configured", o.GetName())
+	}
+	return
 }
 
 func (o *Client) ListModels() (ret []string, err error) {
-	ctx := context.Background()
 	var client *genai.Client
-	if client, err = genai.NewClient(ctx, option.WithAPIKey(o.ApiKey.Value)); err != nil {
+	if client, err = o.getClient(); err != nil {
 		return
 	}
-	defer client.Close()
 
-	iter := client.ListModels(ctx)
+	iter := client.ListModels(context.Background())
 	for {
 		var resp *genai.ModelInfo
 		if resp, err = iter.Next(); err != nil {
@@ -61,25 +84,46 @@ func (o *Client) ListModels() (ret []string, err error) {
 }
 
 func (o *Client) Send(ctx context.Context, msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions) (ret string, err error) {
-	systemInstruction, messages := toMessages(msgs)
+	var session *genai.ChatSession
+	var parts []genai.Part
+	if session, parts, err = o.startChat(ctx, msgs, opts); err != nil {
+		return
+	}
+
+	var response *genai.GenerateContentResponse
+	if response, err = session.SendMessage(ctx, parts...); err != nil {
+		return
+	}
+
+	ret = o.extractText(response)
+	return
+}
 
+// Embed implements ai.Embedder
+func (o *Client) Embed(ctx context.Context, inputs []string, model string) (ret [][]float32, err error) {
 	var client *genai.Client
-	if client, err = genai.NewClient(ctx, option.WithAPIKey(o.ApiKey.Value)); err != nil {
+	if client, err = o.getClient(); err != nil {
 		return
 	}
-	defer client.Close()
 
-	model := client.GenerativeModel(o.buildModelNameFull(opts.Model))
-	model.SetTemperature(float32(opts.Temperature))
-	model.SetTopP(float32(opts.TopP))
-	model.SystemInstruction = systemInstruction
+	embeddingModel := client.EmbeddingModel(model)
+	batch := embeddingModel.NewBatch()
+	for _, input := range inputs {
+		batch.AddContent(genai.Text(input))
+	}
 
-	var response *genai.GenerateContentResponse
-	if response, err = model.GenerateContent(ctx, messages...); err != nil {
+	var resp *genai.BatchEmbedContentsResponse
+	if resp, err = embeddingModel.BatchEmbedContents(ctx, batch); err != nil {
+		return
+	}
+	if len(resp.Embeddings) != len(inputs) {
+		err = fmt.Errorf("got %d embeddings for %d inputs", len(resp.Embeddings), len(inputs))
 		return
 	}
 
-	ret = o.extractText(response)
+	for _, embedding := range resp.Embeddings {
+		ret = append(ret, embedding.Values)
+	}
 	return
 }
 
@@ -93,20 +137,13 @@ func (o *Client) buildModelNameFull(modelName string) string {
 
 func (o *Client) SendStream(msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions, channel chan string) (err error) {
 	ctx := context.Background()
-	var client *genai.Client
-	if client, err = genai.NewClient(ctx, option.WithAPIKey(o.ApiKey.Value)); err != nil {
+	var session *genai.ChatSession
+	var parts []genai.Part
+	if session, parts, err = o.startChat(ctx, msgs, opts); err != nil {
 		return
 	}
-	defer client.Close()
-
-	systemInstruction, messages := toMessages(msgs)
-
-	model := client.GenerativeModel(o.buildModelNameFull(opts.Model))
-	model.SetTemperature(float32(opts.Temperature))
-	model.SetTopP(float32(opts.TopP))
-	model.SystemInstruction = systemInstruction
 
-	iter := model.GenerateContentStream(ctx, messages...)
+	iter := session.SendMessageStream(ctx, parts...)
 	for {
 		if resp, iterErr := iter.Next(); iterErr == nil {
 			for _, candidate := range resp.Candidates {
@@ -129,6 +166,37 @@ func (o *Client) SendStream(msgs []*goopenai.ChatCompletionMessage, opts *common
 	return
 }
 
+// startChat creates a chat session with all but the last message as history
+// and returns the parts of the last message to send
+func (o *Client) startChat(ctx context.Context, msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions) (
+	session *genai.ChatSession, parts []genai.Part, err error) {
+
+	var client *genai.Client
+	if client, err = o.getClient(); err != nil {
+		return
+	}
+
+	var systemInstruction *genai.Content
+	var contents []*genai.Content
+	if systemInstruction, contents, err = toContents(ctx, msgs); err != nil {
+		return
+	}
+	if len(contents) == 0 {
+		err = fmt.Errorf("no message to send")
+		return
+	}
+
+	model := client.GenerativeModel(o.buildModelNameFull(opts.Model))
+	model.SetTemperature(float32(opts.Temperature))
+	model.SetTopP(float32(opts.TopP))
+	model.SystemInstruction = systemInstruction
+
+	session = model.StartChat()
+	session.History = contents[:len(contents)-1]
+	parts = contents[len(contents)-1].Parts
+	return
+}
+
 func (o *Client) extractText(response *genai.GenerateContentResponse) (ret string) {
 	for _, candidate := range response.Candidates {
 		if candidate.Content == nil {
@@ -143,18 +211,69 @@ func (o *Client) extractText(response *genai.GenerateContentResponse) (ret strin
 	return
 }
 
-func toMessages(msgs []*goopenai.ChatCompletionMessage) (systemInstruction *genai.Content, messages []genai.Part) {
-	if len(msgs) >= 2 {
-		systemInstruction = &genai.Content{
-			Parts: []genai.Part{
-				genai.Text(msgs[0].Content),
-			},
+// toContents maps system messages to the system instruction and the other
+// messages to user and model turns. Consecutive messages of the same role
+// are merged, as Gemini expects the roles to alternate.
+func toContents(ctx context.Context, msgs []*goopenai.ChatCompletionMessage) (systemInstruction *genai.Content, contents []*genai.Content, err error) {
+	for _, msg := range msgs {
+		if msg.Role == goopenai.ChatMessageRoleSystem {
+			if msg.Content != "" {
+				if systemInstruction == nil {
+					systemInstruction = &genai.Content{}
+				}
+				systemInstruction.Parts = append(systemInstruction.Parts, genai.Text(msg.Content))
+			}
+			continue
 		}
-		for _, msg := range msgs[1:] {
-			messages = append(messages, genai.Text(msg.Content))
+
+		role := roleUser
+		if msg.Role == goopenai.ChatMessageRoleAssistant {
+			role = roleModel
+		}
+
+		var parts []genai.Part
+		if parts, err = toParts(ctx, msg); err != nil {
+			return
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		if last := len(contents) - 1; last >= 0 && contents[last].Role == role {
+			contents[last].Parts = append(contents[last].Parts, parts...)
+		} else {
+			contents = append(contents, &genai.Content{Role: role, Parts: parts})
 		}
-	} else {
-		messages = append(messages, genai.Text(msgs[0].Content))
 	}
 	return
 }
+
+func toParts(ctx context.Context, msg *goopenai.ChatCompletionMessage) (ret []genai.Part, err error) {
+	if len(msg.MultiContent) == 0 {
+		if msg.Content != "" {
+			ret = append(ret, genai.Text(msg.Content))
+		}
+		return
+	}
+	for _, part := range msg.MultiContent {
+		switch part.Type {
+		case goopenai.ChatMessagePartTypeText:
+			ret = append(ret, genai.Text(part.Text))
+		case goopenai.ChatMessagePartTypeImageURL:
+			if part.ImageURL == nil {
+				continue
+			}
+			var blob genai.Blob
+			if blob, err = toBlob(ctx, part.ImageURL.URL); err != nil {
+				return
+			}
+			ret = append(ret, blob)
+		}
+	}
+	return
+}
+
+func toBlob(ctx context.Context, url string) (ret genai.Blob, err error) {
+	ret.MIMEType, ret.Data, err = common.DecodeImageURL(ctx, url)
+	return
+}
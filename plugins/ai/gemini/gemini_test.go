@@ -1,9 +1,14 @@
 package gemini
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"github.com/google/generative-ai-go/genai"
+	goopenai "github.com/sashabaranov/go-openai"
 )
 
 // Test generated using Keploy
@@ -42,3 +47,77 @@ func TestExtractText(t *testing.T) {
 		t.Errorf("Expected %v, got %v", expected, result)
 	}
 }
+
+func TestToContents(t *testing.T) {
+	pngData := []byte("\x89PNG\r\n\x1a\nimage")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pngData)
+	}))
+	defer server.Close()
+
+	msgs := []*goopenai.ChatCompletionMessage{
+		{Role: goopenai.ChatMessageRoleSystem, Content: "You are a pattern"},
+		{Role: goopenai.ChatMessageRoleUser, Content: "First question"},
+		{Role: goopenai.ChatMessageRoleAssistant, Content: "First answer"},
+		{Role: goopenai.ChatMessageRoleUser, Content: "Some context"},
+		{Role: goopenai.ChatMessageRoleUser, MultiContent: []goopenai.ChatMessagePart{
+			{Type: goopenai.ChatMessagePartTypeText, Text: "Compare"},
+			{Type: goopenai.ChatMessagePartTypeImageURL, ImageURL: &goopenai.ChatMessageImageURL{URL: "data:image/jpeg;base64,AQID"}},
+			{Type: goopenai.ChatMessagePartTypeImageURL, ImageURL: &goopenai.ChatMessageImageURL{URL: server.URL + "/image"}},
+		}},
+	}
+
+	systemInstruction, contents, err := toContents(context.Background(), msgs)
+	if err != nil {
+		t.Fatalf("toContents() error = %v", err)
+	}
+
+	if systemInstruction == nil || !reflect.DeepEqual(systemInstruction.Parts, []genai.Part{genai.Text("You are a pattern")}) {
+		t.Errorf("Expected the system message as system instruction, got %+v", systemInstruction)
+	}
+
+	expected := []*genai.Content{
+		{Role: roleUser, Parts: []genai.Part{genai.Text("First question")}},
+		{Role: roleModel, Parts: []genai.Part{genai.Text("First answer")}},
+		{Role: roleUser, Parts: []genai.Part{
+			genai.Text("Some context"),
+			genai.Text("Compare"),
+			genai.Blob{MIMEType: "image/jpeg", Data: []byte{1, 2, 3}},
+			genai.Blob{MIMEType: "image/png", Data: pngData},
+		}},
+	}
+	if !reflect.DeepEqual(contents, expected) {
+		t.Errorf("Expected contents %+v, got %+v", expected, contents)
+	}
+}
+
+func TestToContentsInvalidImage(t *testing.T) {
+	msgs := []*goopenai.ChatCompletionMessage{
+		{Role: goopenai.ChatMessageRoleUser, MultiContent: []goopenai.ChatMessagePart{
+			{Type: goopenai.ChatMessagePartTypeImageURL, ImageURL: &goopenai.ChatMessageImageURL{URL: "data:image/png;base64,!!!"}},
+		}},
+	}
+	if _, _, err := toContents(context.Background(), msgs); err == nil {
+		t.Error("Expected an error for invalid image data")
+	}
+}
+
+func TestConfigureReusesClient(t *testing.T) {
+	client := NewClient()
+	if _, err := client.ListModels(); err == nil {
+		t.Error("Expected an error before the client is configured")
+	}
+
+	client.ApiKey.Value = "test-key"
+	if err := client.Configure(); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	first, err := client.getClient()
+	if err != nil || first == nil {
+		t.Fatalf("Expected a client after configure, got %v", err)
+	}
+	second, _ := client.getClient()
+	if first != second {
+		t.Error("Expected the same client to be used for every call")
+	}
+}
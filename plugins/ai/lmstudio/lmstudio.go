@@ -4,10 +4,14 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
 
 	goopenai "github.com/sashabaranov/go-openai"
 
@@ -88,8 +92,14 @@ func (c *Client) ListModels() ([]string, error) {
 }
 
 func (c *Client) SendStream(msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions, channel chan string) (err error) {
+	defer close(channel)
+
 	url := fmt.Sprintf("%s/chat/completions", c.ApiUrl.Value)
 
+	if msgs, err = c.prepareMessages(context.Background(), msgs, opts.Model); err != nil {
+		return
+	}
+
 	payload := map[string]interface{}{
 		"messages": msgs,
 		"model":    opts.Model,
@@ -122,8 +132,6 @@ func (c *Client) SendStream(msgs []*goopenai.ChatCompletionMessage, opts *common
 		return
 	}
 
-	defer close(channel)
-
 	reader := bufio.NewReader(resp.Body)
 	for {
 		var line []byte
@@ -176,6 +184,10 @@ func (c *Client) SendStream(msgs []*goopenai.ChatCompletionMessage, opts *common
 func (c *Client) Send(ctx context.Context, msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions) (content string, err error) {
 	url := fmt.Sprintf("%s/chat/completions", c.ApiUrl.Value)
 
+	if msgs, err = c.prepareMessages(ctx, msgs, opts.Model); err != nil {
+		return
+	}
+
 	payload := map[string]interface{}{
 		"messages": msgs,
 		"model":    opts.Model,
@@ -291,13 +303,27 @@ func (c *Client) Complete(ctx context.Context, prompt string, opts *common.ChatO
 	return
 }
 
+// GetEmbeddings returns the embedding of a single input
 func (c *Client) GetEmbeddings(ctx context.Context, input string, opts *common.ChatOptions) (embeddings []float64, err error) {
+	var vectors [][]float32
+	if vectors, err = c.Embed(ctx, []string{input}, opts.Model); err != nil {
+		return
+	}
+
+	embeddings = make([]float64, len(vectors[0]))
+	for i, value := range vectors[0] {
+		embeddings[i] = float64(value)
+	}
+	return
+}
+
+// Embed implements ai.Embedder
+func (c *Client) Embed(ctx context.Context, inputs []string, model string) (ret [][]float32, err error) {
 	url := fmt.Sprintf("%s/embeddings", c.ApiUrl.Value)
 
 	payload := map[string]interface{}{
-		"input": input,
-		"model": opts.Model,
-		// Add other options from opts if supported by LM Studio
+		"input": inputs,
+		"model": model,
 	}
 
 	var jsonPayload []byte
@@ -328,7 +354,8 @@ func (c *Client) GetEmbeddings(ctx context.Context, input string, opts *common.C
 
 	var result struct {
 		Data []struct {
-			Embedding []float64 `json:"embedding"`
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
 		} `json:"data"`
 	}
 
@@ -337,11 +364,91 @@ func (c *Client) GetEmbeddings(ctx context.Context, input string, opts *common.C
 		return
 	}
 
-	if len(result.Data) == 0 {
-		err = fmt.Errorf("no embeddings returned")
+	if len(result.Data) != len(inputs) {
+		err = fmt.Errorf("got %d embeddings for %d inputs", len(result.Data), len(inputs))
 		return
 	}
 
-	embeddings = result.Data[0].Embedding
+	ret = make([][]float32, len(inputs))
+	for _, data := range result.Data {
+		if data.Index < 0 || data.Index >= len(ret) {
+			err = fmt.Errorf("unexpected embedding index %d", data.Index)
+			return
+		}
+		ret[data.Index] = data.Embedding
+	}
+	for i, embedding := range ret {
+		if embedding == nil {
+			err = fmt.Errorf("no embedding for input %d", i)
+			return
+		}
+	}
+	return
+}
+
+// prepareMessages inlines remote images as data URLs, the only image URLs
+// LM Studio accepts, and warns when the model cannot see images.
+func (c *Client) prepareMessages(ctx context.Context, msgs []*goopenai.ChatCompletionMessage, model string) (ret []*goopenai.ChatCompletionMessage, err error) {
+	hasImages := false
+	for _, msg := range msgs {
+		if len(msg.MultiContent) == 0 {
+			ret = append(ret, msg)
+			continue
+		}
+		prepared := *msg
+		prepared.MultiContent = make([]goopenai.ChatMessagePart, len(msg.MultiContent))
+		for i, part := range msg.MultiContent {
+			if part.Type == goopenai.ChatMessagePartTypeImageURL && part.ImageURL != nil {
+				hasImages = true
+				if !strings.HasPrefix(part.ImageURL.URL, "data:") {
+					var mimeType string
+					var data []byte
+					if mimeType, data, err = common.DecodeImageURL(ctx, part.ImageURL.URL); err != nil {
+						return
+					}
+					imageURL := *part.ImageURL
+					imageURL.URL = fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(data))
+					part.ImageURL = &imageURL
+				}
+			}
+			prepared.MultiContent[i] = part
+		}
+		ret = append(ret, &prepared)
+	}
+
+	if hasImages {
+		c.warnIfNotVisionCapable(ctx, model)
+	}
 	return
 }
+
+// warnIfNotVisionCapable looks the model up in the LM Studio REST API. Nothing
+// is reported when the API is not available, e.g. for other compatible servers.
+func (c *Client) warnIfNotVisionCapable(ctx context.Context, model string) {
+	baseURL := strings.TrimSuffix(strings.TrimSuffix(c.ApiUrl.Value, "/"), "/v1")
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v0/models/%s", baseURL, url.PathEscape(model)), nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	var result struct {
+		Type string `json:"type"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil || result.Type == "" {
+		return
+	}
+
+	if result.Type != "vlm" {
+		fmt.Fprintf(os.Stderr, "Warning: %s model %s is not vision-capable, attached images will be ignored. Load a vision model (VLM) in %s.\n", c.GetName(), model, c.GetName())
+	}
+}
@@ -0,0 +1,171 @@
+package lmstudio
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	goopenai "github.com/sashabaranov/go-openai"
+
+	"github.com/danielmiessler/fabric/common"
+)
+
+func TestSendWithImages(t *testing.T) {
+	var modelLookups int
+	var got struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		io.WriteString(w, "hello")
+	})
+	// model ids may contain slashes and spaces
+	mux.HandleFunc("/api/v0/models/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "qwen/qwen2.5-vl 7b" {
+			http.NotFound(w, r)
+			return
+		}
+		modelLookups++
+		io.WriteString(w, `{"id": "qwen/qwen2.5-vl 7b", "type": "vlm"}`)
+	})
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		io.WriteString(w, `{"choices": [{"message": {"role": "assistant", "content": "a cat"}}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient()
+	client.ApiUrl.Value = server.URL + "/v1"
+	if err := client.Configure(); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := []*goopenai.ChatCompletionMessage{
+		{Role: goopenai.ChatMessageRoleSystem, Content: "be brief"},
+		{Role: goopenai.ChatMessageRoleUser, MultiContent: []goopenai.ChatMessagePart{
+			{Type: goopenai.ChatMessagePartTypeText, Text: "what is it?"},
+			{Type: goopenai.ChatMessagePartTypeImageURL, ImageURL: &goopenai.ChatMessageImageURL{URL: server.URL + "/image.png"}},
+		}},
+	}
+	answer, err := client.Send(context.Background(), msgs, &common.ChatOptions{Model: "qwen/qwen2.5-vl 7b"})
+	if err != nil || answer != "a cat" {
+		t.Fatalf("Send() = %q, %v", answer, err)
+	}
+
+	if modelLookups != 1 {
+		t.Errorf("model looked up %d times, want 1", modelLookups)
+	}
+	if len(got.Messages) != 2 || string(got.Messages[0].Content) != `"be brief"` {
+		t.Fatalf("unexpected messages %+v", got.Messages)
+	}
+	var parts []goopenai.ChatMessagePart
+	if err = json.Unmarshal(got.Messages[1].Content, &parts); err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 2 || parts[0].Text != "what is it?" || parts[1].ImageURL.URL != "data:image/png;base64,aGVsbG8=" {
+		t.Errorf("unexpected parts %+v", parts)
+	}
+	// the caller's messages are left untouched
+	if msgs[1].MultiContent[1].ImageURL.URL != server.URL+"/image.png" {
+		t.Errorf("original message changed to %s", msgs[1].MultiContent[1].ImageURL.URL)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected [][]float32
+		err      string
+	}{
+		{
+			name:     "unordered",
+			response: `{"data": [{"index": 1, "embedding": [0.3]}, {"index": 0, "embedding": [0.1]}]}`,
+			expected: [][]float32{{0.1}, {0.3}},
+		},
+		{
+			name:     "missing embedding",
+			response: `{"data": [{"index": 0, "embedding": [0.1]}]}`,
+			err:      "got 1 embeddings for 2 inputs",
+		},
+		{
+			name:     "duplicate index",
+			response: `{"data": [{"index": 0, "embedding": [0.1]}, {"index": 0, "embedding": [0.3]}]}`,
+			err:      "no embedding for input 1",
+		},
+		{
+			name:     "index out of range",
+			response: `{"data": [{"index": 0, "embedding": [0.1]}, {"index": 2, "embedding": [0.3]}]}`,
+			err:      "unexpected embedding index 2",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tc.response)
+			}))
+			defer server.Close()
+
+			client := NewClient()
+			client.ApiUrl.Value = server.URL + "/v1"
+			if err := client.Configure(); err != nil {
+				t.Fatal(err)
+			}
+
+			embeddings, err := client.Embed(context.Background(), []string{"a", "b"}, "nomic-embed")
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("Embed() error = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Embed() error = %v", err)
+			}
+			if !reflect.DeepEqual(embeddings, tc.expected) {
+				t.Errorf("Embed() = %v, want %v", embeddings, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSendStreamClosesChannelOnError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v0/models/{id}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"id": "llava", "type": "vlm"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient()
+	client.ApiUrl.Value = server.URL + "/v1"
+	if err := client.Configure(); err != nil {
+		t.Fatal(err)
+	}
+
+	channel := make(chan string)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- client.SendStream([]*goopenai.ChatCompletionMessage{
+			{Role: goopenai.ChatMessageRoleUser, MultiContent: []goopenai.ChatMessagePart{
+				{Type: goopenai.ChatMessagePartTypeImageURL, ImageURL: &goopenai.ChatMessageImageURL{URL: server.URL + "/missing.png"}},
+			}},
+		}, &common.ChatOptions{Model: "llava"}, channel)
+	}()
+	for range channel {
+	}
+	if err := <-errs; err == nil {
+		t.Error("expected an error for a missing image")
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
 	"time"
 
 	ollamaapi "github.com/ollama/ollama/api"
+	ollamamodel "github.com/ollama/ollama/types/model"
 	"github.com/samber/lo"
 	goopenai "github.com/sashabaranov/go-openai"
 
@@ -64,27 +66,31 @@ func (o *Client) ListModels() (ret []string, err error) {
 }
 
 func (o *Client) SendStream(msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions, channel chan string) (err error) {
-	req := o.createChatRequest(msgs, opts)
+	defer close(channel)
 
-	respFunc := func(resp ollamaapi.ChatResponse) (streamErr error) {
-		channel <- resp.Message.Content
+	ctx := context.Background()
+
+	var req ollamaapi.ChatRequest
+	if req, err = o.createChatRequest(ctx, msgs, opts); err != nil {
 		return
 	}
 
-	ctx := context.Background()
-
-	if err = o.client.Chat(ctx, &req, respFunc); err != nil {
+	respFunc := func(resp ollamaapi.ChatResponse) (streamErr error) {
+		channel <- resp.Message.Content
 		return
 	}
 
-	close(channel)
+	err = o.client.Chat(ctx, &req, respFunc)
 	return
 }
 
 func (o *Client) Send(ctx context.Context, msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions) (ret string, err error) {
 	bf := false
 
-	req := o.createChatRequest(msgs, opts)
+	var req ollamaapi.ChatRequest
+	if req, err = o.createChatRequest(ctx, msgs, opts); err != nil {
+		return
+	}
 	req.Stream = &bf
 
 	respFunc := func(resp ollamaapi.ChatResponse) (streamErr error) {
@@ -98,10 +104,28 @@ func (o *Client) Send(ctx context.Context, msgs []*goopenai.ChatCompletionMessag
 	return
 }
 
-func (o *Client) createChatRequest(msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions) (ret ollamaapi.ChatRequest) {
-	messages := lo.Map(msgs, func(message *goopenai.ChatCompletionMessage, _ int) (ret ollamaapi.Message) {
-		return ollamaapi.Message{Role: message.Role, Content: message.Content}
-	})
+// Embed implements ai.Embedder
+func (o *Client) Embed(ctx context.Context, inputs []string, model string) (ret [][]float32, err error) {
+	var resp *ollamaapi.EmbedResponse
+	if resp, err = o.client.Embed(ctx, &ollamaapi.EmbedRequest{Model: model, Input: inputs}); err != nil {
+		return
+	}
+	if len(resp.Embeddings) != len(inputs) {
+		err = fmt.Errorf("got %d embeddings for %d inputs", len(resp.Embeddings), len(inputs))
+		return
+	}
+	ret = resp.Embeddings
+	return
+}
+
+func (o *Client) createChatRequest(ctx context.Context, msgs []*goopenai.ChatCompletionMessage, opts *common.ChatOptions) (ret ollamaapi.ChatRequest, err error) {
+	var messages []ollamaapi.Message
+	if messages, err = toMessages(ctx, msgs); err != nil {
+		return
+	}
+	if lo.SomeBy(messages, func(message ollamaapi.Message) bool { return len(message.Images) > 0 }) {
+		o.warnIfNotVisionCapable(ctx, opts.Model)
+	}
 
 	options := map[string]interface{}{
 		"temperature":       opts.Temperature,
@@ -121,3 +145,49 @@ func (o *Client) createChatRequest(msgs []*goopenai.ChatCompletionMessage, opts
 	}
 	return
 }
+
+// toMessages converts image parts of messages, as built for attachments, to
+// the images of Ollama messages
+func toMessages(ctx context.Context, msgs []*goopenai.ChatCompletionMessage) (ret []ollamaapi.Message, err error) {
+	for _, msg := range msgs {
+		message := ollamaapi.Message{Role: msg.Role, Content: msg.Content}
+		for _, part := range msg.MultiContent {
+			switch part.Type {
+			case goopenai.ChatMessagePartTypeText:
+				if message.Content != "" {
+					message.Content += "\n"
+				}
+				message.Content += part.Text
+			case goopenai.ChatMessagePartTypeImageURL:
+				if part.ImageURL == nil {
+					continue
+				}
+				var data []byte
+				if _, data, err = common.DecodeImageURL(ctx, part.ImageURL.URL); err != nil {
+					return
+				}
+				message.Images = append(message.Images, data)
+			}
+		}
+		ret = append(ret, message)
+	}
+	return
+}
+
+// warnIfNotVisionCapable tells the user when images will be ignored by the
+// model. Nothing is reported when the model cannot be inspected.
+func (o *Client) warnIfNotVisionCapable(ctx context.Context, model string) {
+	show, err := o.client.Show(ctx, &ollamaapi.ShowRequest{Model: model})
+	if err != nil {
+		return
+	}
+	if isVisionCapable(show) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: Ollama model %s is not vision-capable, attached images will be ignored. Use a vision model such as llava or qwen2.5vl.\n", model)
+}
+
+func isVisionCapable(show *ollamaapi.ShowResponse) bool {
+	// servers before capabilities were reported list a projector for vision models
+	return lo.Contains(show.Capabilities, ollamamodel.CapabilityVision) || len(show.ProjectorInfo) > 0
+}
@@ -0,0 +1,154 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ollamaapi "github.com/ollama/ollama/api"
+	ollamamodel "github.com/ollama/ollama/types/model"
+	goopenai "github.com/sashabaranov/go-openai"
+
+	"github.com/danielmiessler/fabric/common"
+)
+
+func TestToMessages(t *testing.T) {
+	msgs := []*goopenai.ChatCompletionMessage{
+		{Role: goopenai.ChatMessageRoleSystem, Content: "be brief"},
+		{Role: goopenai.ChatMessageRoleUser, MultiContent: []goopenai.ChatMessagePart{
+			{Type: goopenai.ChatMessagePartTypeText, Text: "describe"},
+			{Type: goopenai.ChatMessagePartTypeImageURL, ImageURL: &goopenai.ChatMessageImageURL{URL: "data:image/png;base64,aGVsbG8="}},
+		}},
+	}
+
+	messages, err := toMessages(context.Background(), msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+	if messages[0].Content != "be brief" || len(messages[0].Images) != 0 {
+		t.Errorf("unexpected system message %+v", messages[0])
+	}
+	if messages[1].Content != "describe" || len(messages[1].Images) != 1 || string(messages[1].Images[0]) != "hello" {
+		t.Errorf("unexpected user message %+v", messages[1])
+	}
+
+	msgs[1].MultiContent[1].ImageURL.URL = "data:image/png;base64,%%%"
+	if _, err = toMessages(context.Background(), msgs); err == nil {
+		t.Error("expected an error for an invalid image")
+	}
+}
+
+func TestIsVisionCapable(t *testing.T) {
+	testCases := []struct {
+		name string
+		show ollamaapi.ShowResponse
+		want bool
+	}{
+		{name: "capability", show: ollamaapi.ShowResponse{Capabilities: []ollamamodel.Capability{ollamamodel.CapabilityCompletion, ollamamodel.CapabilityVision}}, want: true},
+		{name: "projector", show: ollamaapi.ShowResponse{ProjectorInfo: map[string]any{"clip.has_vision_encoder": true}}, want: true},
+		{name: "text only", show: ollamaapi.ShowResponse{Capabilities: []ollamamodel.Capability{ollamamodel.CapabilityCompletion}}, want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isVisionCapable(&tc.show); got != tc.want {
+				t.Errorf("isVisionCapable() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSendWithImages(t *testing.T) {
+	var got ollamaapi.ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/show":
+			json.NewEncoder(w).Encode(ollamaapi.ShowResponse{Capabilities: []ollamamodel.Capability{ollamamodel.CapabilityVision}})
+		case "/api/chat":
+			json.NewDecoder(r.Body).Decode(&got)
+			json.NewEncoder(w).Encode(ollamaapi.ChatResponse{Message: ollamaapi.Message{Role: "assistant", Content: "a cat"}, Done: true})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.ApiUrl.Value = server.URL
+	if err := client.Configure(); err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := client.Send(context.Background(), []*goopenai.ChatCompletionMessage{
+		{Role: goopenai.ChatMessageRoleUser, MultiContent: []goopenai.ChatMessagePart{
+			{Type: goopenai.ChatMessagePartTypeText, Text: "what is it?"},
+			{Type: goopenai.ChatMessagePartTypeImageURL, ImageURL: &goopenai.ChatMessageImageURL{URL: "data:image/png;base64,aGVsbG8="}},
+		}},
+	}, &common.ChatOptions{Model: "llava"})
+	if err != nil || answer != "a cat" {
+		t.Fatalf("Send() = %q, %v", answer, err)
+	}
+	if len(got.Messages) != 1 || len(got.Messages[0].Images) != 1 || string(got.Messages[0].Images[0]) != "hello" {
+		t.Errorf("unexpected request %+v", got.Messages)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaapi.EmbedRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/embed" || req.Model != "nomic-embed-text" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(ollamaapi.EmbedResponse{Model: req.Model, Embeddings: [][]float32{{0.1}, {0.2}}})
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.ApiUrl.Value = server.URL
+	if err := client.Configure(); err != nil {
+		t.Fatal(err)
+	}
+
+	embeddings, err := client.Embed(context.Background(), []string{"a", "b"}, "nomic-embed-text")
+	if err != nil || len(embeddings) != 2 || embeddings[1][0] != 0.2 {
+		t.Fatalf("Embed() = %v, %v", embeddings, err)
+	}
+}
+
+func TestSendStreamClosesChannelOnError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ollamaapi.ShowResponse{Capabilities: []ollamamodel.Capability{ollamamodel.CapabilityVision}})
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.ApiUrl.Value = server.URL
+	if err := client.Configure(); err != nil {
+		t.Fatal(err)
+	}
+
+	channel := make(chan string)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- client.SendStream([]*goopenai.ChatCompletionMessage{
+			{Role: goopenai.ChatMessageRoleUser, MultiContent: []goopenai.ChatMessagePart{
+				{Type: goopenai.ChatMessagePartTypeImageURL, ImageURL: &goopenai.ChatMessageImageURL{URL: "data:image/png;base64,%%%"}},
+			}},
+		}, &common.ChatOptions{Model: "llava"}, channel)
+	}()
+	for range channel {
+	}
+	if err := <-errs; err == nil {
+		t.Error("expected an error for an invalid image")
+	}
+}
@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
+	"os"
+	"strings"
 
 	"github.com/danielmiessler/fabric/plugins"
+	"github.com/danielmiessler/fabric/plugins/ai"
 
 	"github.com/danielmiessler/fabric/common"
 	"github.com/samber/lo"
@@ -19,7 +23,14 @@ func NewClient() (ret *Client) {
 }
 
 func NewClientCompatible(vendorName string, defaultBaseUrl string, configureCustom func() error) (ret *Client) {
+	return NewClientCompatibleWithEnvPrefix(vendorName, plugins.BuildEnvVariablePrefix(vendorName), defaultBaseUrl, configureCustom)
+}
+
+// NewClientCompatibleWithEnvPrefix stores the settings of the vendor in
+// variables starting with envNamePrefix instead of the vendor name
+func NewClientCompatibleWithEnvPrefix(vendorName string, envNamePrefix string, defaultBaseUrl string, configureCustom func() error) (ret *Client) {
 	ret = NewClientCompatibleNoSetupQuestions(vendorName, configureCustom)
+	ret.EnvNamePrefix = envNamePrefix
 
 	ret.ApiKey = ret.AddSetupQuestion("API Key", true)
 	ret.ApiBaseURL = ret.AddSetupQuestion("API Base URL", false)
@@ -49,6 +60,12 @@ type Client struct {
 	ApiKey     *plugins.SetupQuestion
 	ApiBaseURL *plugins.SetupQuestion
 	ApiClient  *openai.Client
+
+	// Headers are added to every request, values may reference environment
+	// variables as $NAME or ${NAME}
+	Headers map[string]string
+	// Models is returned by ListModels instead of asking the API
+	Models []string
 }
 
 func (o *Client) configure() (ret error) {
@@ -56,11 +73,37 @@ func (o *Client) configure() (ret error) {
 	if o.ApiBaseURL.Value != "" {
 		config.BaseURL = o.ApiBaseURL.Value
 	}
+	if len(o.Headers) > 0 {
+		headers := make(http.Header, len(o.Headers))
+		for name, value := range o.Headers {
+			headers.Set(name, os.ExpandEnv(value))
+		}
+		config.HTTPClient = &http.Client{Transport: &headerTransport{headers: headers, base: http.DefaultTransport}}
+	}
 	o.ApiClient = openai.NewClientWithConfig(config)
 	return
 }
 
+// headerTransport adds headers to the requests of the API client
+type headerTransport struct {
+	headers http.Header
+	base    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		req.Header[name] = values
+	}
+	return t.base.RoundTrip(req)
+}
+
 func (o *Client) ListModels() (ret []string, err error) {
+	if len(o.Models) > 0 {
+		ret = append(ret, o.Models...)
+		return
+	}
+
 	var models openai.ModelsList
 	if models, err = o.ApiClient.ListModels(context.Background()); err != nil {
 		return
@@ -124,6 +167,68 @@ func (o *Client) Send(ctx context.Context, msgs []*openai.ChatCompletionMessage,
 	return
 }
 
+// Embed implements ai.Embedder, for Azure deployments the model is the
+// deployment name
+func (o *Client) Embed(ctx context.Context, inputs []string, model string) (ret [][]float32, err error) {
+	var resp openai.EmbeddingResponse
+	if resp, err = o.ApiClient.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: inputs,
+		Model: openai.EmbeddingModel(model),
+	}); err != nil {
+		return
+	}
+
+	if len(resp.Data) != len(inputs) {
+		err = fmt.Errorf("got %d embeddings for %d inputs", len(resp.Data), len(inputs))
+		return
+	}
+	ret = make([][]float32, len(inputs))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(ret) {
+			err = fmt.Errorf("unexpected embedding index %d", data.Index)
+			return
+		}
+		ret[data.Index] = data.Embedding
+	}
+	for i, embedding := range ret {
+		if embedding == nil {
+			err = fmt.Errorf("no embedding for input %d", i)
+			return
+		}
+	}
+	return
+}
+
+// Transcribe implements ai.Transcriber with the /audio/transcriptions
+// endpoint, timestamps need the verbose_json response format
+func (o *Client) Transcribe(ctx context.Context, filePath string, model string, language string, timestamps bool) (ret *ai.Transcription, err error) {
+	req := openai.AudioRequest{
+		Model:    model,
+		FilePath: filePath,
+		Language: language,
+		Format:   openai.AudioResponseFormatJSON,
+	}
+	if timestamps {
+		req.Format = openai.AudioResponseFormatVerboseJSON
+		req.TimestampGranularities = []openai.TranscriptionTimestampGranularity{openai.TranscriptionTimestampGranularitySegment}
+	}
+
+	var resp openai.AudioResponse
+	if resp, err = o.ApiClient.CreateTranscription(ctx, req); err != nil {
+		return
+	}
+
+	ret = &ai.Transcription{Text: strings.TrimSpace(resp.Text), Duration: resp.Duration}
+	for _, segment := range resp.Segments {
+		ret.Segments = append(ret.Segments, ai.TranscriptionSegment{
+			Start: segment.Start,
+			End:   segment.End,
+			Text:  strings.TrimSpace(segment.Text),
+		})
+	}
+	return
+}
+
 func (o *Client) buildChatCompletionRequest(
 	msgs []*openai.ChatCompletionMessage, opts *common.ChatOptions,
 ) (ret openai.ChatCompletionRequest) {
@@ -1,9 +1,16 @@
 package openai
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/plugins/ai"
 	"github.com/sashabaranov/go-openai"
 	goopenai "github.com/sashabaranov/go-openai"
 	"github.com/stretchr/testify/assert"
@@ -100,3 +107,88 @@ func TestBuildChatCompletionRequestNilSeed(t *testing.T) {
 	request := client.buildChatCompletionRequest(msgs, opts)
 	assert.Equal(t, expectedRequest, request)
 }
+
+func TestEmbed(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected [][]float32
+		err      string
+	}{
+		{
+			// the API may return the embeddings in any order
+			name:     "unordered",
+			response: `{"data": [{"index": 1, "embedding": [0.3, 0.4]}, {"index": 0, "embedding": [0.1, 0.2]}]}`,
+			expected: [][]float32{{0.1, 0.2}, {0.3, 0.4}},
+		},
+		{
+			name:     "missing embedding",
+			response: `{"data": [{"index": 0, "embedding": [0.1, 0.2]}]}`,
+			err:      "got 1 embeddings for 2 inputs",
+		},
+		{
+			name:     "duplicate index",
+			response: `{"data": [{"index": 0, "embedding": [0.1, 0.2]}, {"index": 0, "embedding": [0.3, 0.4]}]}`,
+			err:      "no embedding for input 1",
+		},
+		{
+			name:     "index out of range",
+			response: `{"data": [{"index": 0, "embedding": [0.1, 0.2]}, {"index": 2, "embedding": [0.3, 0.4]}]}`,
+			err:      "unexpected embedding index 2",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, tc.response)
+			}))
+			defer server.Close()
+
+			client := NewClient()
+			client.ApiKey.Value = "key"
+			client.ApiBaseURL.Value = server.URL
+			assert.NoError(t, client.Configure())
+
+			embeddings, err := client.Embed(context.Background(), []string{"a", "b"}, "text-embedding-3-small")
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, embeddings)
+		})
+	}
+}
+
+func TestTranscribe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/audio/transcriptions", r.URL.Path)
+		assert.NoError(t, r.ParseMultipartForm(1024))
+		assert.Equal(t, "whisper-1", r.FormValue("model"))
+		assert.Equal(t, "verbose_json", r.FormValue("response_format"))
+		assert.Equal(t, "en", r.FormValue("language"))
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"text": " Hello there. ", "duration": 4.5,
+			"segments": [{"start": 0, "end": 2, "text": " Hello"}, {"start": 2, "end": 4.5, "text": " there."}]}`)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.ApiKey.Value = "key"
+	client.ApiBaseURL.Value = server.URL
+	assert.NoError(t, client.Configure())
+
+	audioPath := filepath.Join(t.TempDir(), "meeting.mp3")
+	assert.NoError(t, os.WriteFile(audioPath, []byte("audio"), 0644))
+
+	transcription, err := client.Transcribe(context.Background(), audioPath, "whisper-1", "en", true)
+	assert.NoError(t, err)
+	assert.Equal(t, &ai.Transcription{
+		Text:     "Hello there.",
+		Duration: 4.5,
+		Segments: []ai.TranscriptionSegment{{Start: 0, End: 2, Text: "Hello"}, {Start: 2, End: 4.5, Text: "there."}},
+	}, transcription)
+}
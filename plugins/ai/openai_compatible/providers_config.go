@@ -1,13 +1,33 @@
 package openai_compatible
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/danielmiessler/fabric/plugins"
 	"github.com/danielmiessler/fabric/plugins/ai/openai"
 )
 
+// ProvidersFileName is the file in the config directory defining additional providers
+const ProvidersFileName = "providers.yaml"
+
 // ProviderConfig defines the configuration for an OpenAI-compatible API provider
 type ProviderConfig struct {
-	Name    string
-	BaseURL string
+	Name    string `yaml:"name"`
+	BaseURL string `yaml:"base_url"`
+	// EnvPrefix names the .env variables of the provider, e.g. VLLM for
+	// VLLM_API_KEY; the name is used when empty
+	EnvPrefix string `yaml:"env_prefix"`
+	// Models is a static model list for servers without a models endpoint
+	Models []string `yaml:"models"`
+	// Headers are sent with every request, $NAME references are expanded
+	Headers map[string]string `yaml:"headers"`
+	// APIKeyRequired false makes the API key optional, for local servers
+	// without authentication
+	APIKeyRequired *bool `yaml:"api_key_required"`
 }
 
 // Client is the common structure for all OpenAI-compatible providers
@@ -17,8 +37,18 @@ type Client struct {
 
 // NewClient creates a new OpenAI-compatible client for the specified provider
 func NewClient(providerConfig ProviderConfig) *Client {
+	envPrefix := providerConfig.EnvPrefix
+	if envPrefix == "" {
+		envPrefix = providerConfig.Name
+	}
 	client := &Client{}
-	client.Client = openai.NewClientCompatible(providerConfig.Name, providerConfig.BaseURL, nil)
+	client.Client = openai.NewClientCompatibleWithEnvPrefix(providerConfig.Name,
+		plugins.BuildEnvVariablePrefix(envPrefix), providerConfig.BaseURL, nil)
+	client.Headers = providerConfig.Headers
+	client.Models = providerConfig.Models
+	if providerConfig.APIKeyRequired != nil {
+		client.ApiKey.Required = *providerConfig.APIKeyRequired
+	}
 	return client
 }
 
@@ -72,3 +102,48 @@ func CreateClient(providerName string) (*Client, bool) {
 	}
 	return NewClient(providerConfig), true
 }
+
+// LoadProviders reads user-defined providers from a YAML file:
+//
+//	providers:
+//	  - name: vLLM
+//	    base_url: http://gpu-box:8000/v1
+//	    env_prefix: VLLM
+//	    models: [meta-llama/Llama-3.1-8B-Instruct]
+//	    headers:
+//	      X-Team: research
+//	    api_key_required: false
+//
+// A missing file defines no providers.
+func LoadProviders(configPath string) (ret []ProviderConfig, err error) {
+	var data []byte
+	if data, err = os.ReadFile(configPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+
+	var config struct {
+		Providers []ProviderConfig `yaml:"providers"`
+	}
+	if err = yaml.Unmarshal(data, &config); err != nil {
+		err = fmt.Errorf("failed to parse %s: %w", configPath, err)
+		return
+	}
+
+	names := map[string]bool{}
+	for _, provider := range config.Providers {
+		if provider.Name == "" || provider.BaseURL == "" {
+			err = fmt.Errorf("%s: providers need a name and a base_url", configPath)
+			return
+		}
+		if names[provider.Name] {
+			err = fmt.Errorf("%s: provider %s is defined twice", configPath, provider.Name)
+			return
+		}
+		names[provider.Name] = true
+	}
+	ret = config.Providers
+	return
+}
@@ -1,7 +1,17 @@
 package openai_compatible
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+
+	goopenai "github.com/sashabaranov/go-openai"
+
+	"github.com/danielmiessler/fabric/common"
 )
 
 func TestCreateClient(t *testing.T) {
@@ -40,3 +50,144 @@ func TestCreateClient(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadProviders(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ProvidersFileName)
+
+	providers, err := LoadProviders(configPath)
+	if err != nil || len(providers) != 0 {
+		t.Fatalf("missing file: got %v, %v", providers, err)
+	}
+
+	testCases := []struct {
+		name    string
+		config  string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			config: `providers:
+  - name: vLLM
+    base_url: http://localhost:8000/v1
+    env_prefix: VLLM
+    models: [llama]
+  - name: TGI
+    base_url: http://localhost:8080/v1
+`,
+			want: 2,
+		},
+		{
+			name:    "missing base url",
+			config:  "providers:\n  - name: vLLM\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate name",
+			config:  "providers:\n  - name: a\n    base_url: http://a\n  - name: a\n    base_url: http://b\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			config:  "providers: [",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := os.WriteFile(configPath, []byte(tc.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			providers, err := LoadProviders(configPath)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("LoadProviders() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if len(providers) != tc.want {
+				t.Errorf("got %d providers, want %d", len(providers), tc.want)
+			}
+		})
+	}
+}
+
+func TestUserProviderClient(t *testing.T) {
+	var gotHeader, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Team")
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"choices": [{"message": {"role": "assistant", "content": "hello"}}]}`)
+	}))
+	defer server.Close()
+
+	t.Setenv("TEAM_NAME", "research")
+	client := NewClient(ProviderConfig{
+		Name:      "My vLLM",
+		BaseURL:   server.URL,
+		EnvPrefix: "VLLM",
+		Models:    []string{"llama"},
+		Headers:   map[string]string{"X-Team": "${TEAM_NAME}"},
+	})
+
+	if client.ApiKey.EnvVariable != "VLLM_API_KEY" {
+		t.Errorf("API key variable = %s, want VLLM_API_KEY", client.ApiKey.EnvVariable)
+	}
+	client.ApiKey.Value = "secret"
+	if err := client.Configure(); err != nil {
+		t.Fatal(err)
+	}
+
+	models, err := client.ListModels()
+	if err != nil || len(models) != 1 || models[0] != "llama" {
+		t.Errorf("ListModels() = %v, %v", models, err)
+	}
+
+	answer, err := client.Send(context.Background(),
+		[]*goopenai.ChatCompletionMessage{{Role: goopenai.ChatMessageRoleUser, Content: "hi"}},
+		&common.ChatOptions{Model: "llama"})
+	if err != nil || answer != "hello" {
+		t.Fatalf("Send() = %q, %v", answer, err)
+	}
+	if gotHeader != "research" || gotAuth != "Bearer secret" {
+		t.Errorf("headers X-Team=%q Authorization=%q", gotHeader, gotAuth)
+	}
+}
+
+func TestUserProviderWithoutAPIKey(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"choices": [{"message": {"role": "assistant", "content": "hello"}}]}`)
+	}))
+	defer server.Close()
+
+	required := false
+	client := NewClient(ProviderConfig{
+		Name:           "llama.cpp",
+		BaseURL:        server.URL,
+		EnvPrefix:      "LLAMA_CPP_TEST",
+		APIKeyRequired: &required,
+	})
+	if err := client.Configure(); err != nil {
+		t.Fatal(err)
+	}
+	if !client.IsConfigured() {
+		t.Error("provider without API key is not configured")
+	}
+
+	answer, err := client.Send(context.Background(),
+		[]*goopenai.ChatCompletionMessage{{Role: goopenai.ChatMessageRoleUser, Content: "hi"}},
+		&common.ChatOptions{Model: "llama"})
+	if err != nil || answer != "hello" {
+		t.Fatalf("Send() = %q, %v", answer, err)
+	}
+	if gotAuth != "" {
+		t.Errorf("Authorization = %q, want none", gotAuth)
+	}
+
+	if NewClient(ProviderConfig{Name: "Keyed", BaseURL: server.URL}).IsConfigured() {
+		t.Error("provider without API key is configured although the key is required")
+	}
+}
@@ -15,3 +15,30 @@ type Vendor interface {
 	SendStream([]*goopenai.ChatCompletionMessage, *common.ChatOptions, chan string) error
 	Send(context.Context, []*goopenai.ChatCompletionMessage, *common.ChatOptions) (string, error)
 }
+
+// Embedder is implemented by vendors that can turn texts into embedding
+// vectors, the vectors are returned in the order of the inputs
+type Embedder interface {
+	Embed(ctx context.Context, inputs []string, model string) ([][]float32, error)
+}
+
+// Transcriber is implemented by vendors with a speech to text endpoint
+type Transcriber interface {
+	Transcribe(ctx context.Context, filePath string, model string, language string, timestamps bool) (*Transcription, error)
+}
+
+// Transcription is the text of an audio file, the segments are only set
+// when timestamps were requested
+type Transcription struct {
+	Text     string
+	Duration float64 // seconds
+	Segments []TranscriptionSegment
+}
+
+// TranscriptionSegment is a part of a transcription, Start and End are in
+// seconds from the beginning of the file
+type TranscriptionSegment struct {
+	Start float64
+	End   float64
+	Text  string
+}
@@ -1,32 +1,124 @@
 package fsdb
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danielmiessler/fabric/plugins/template"
+	"gopkg.in/yaml.v3"
+)
 
 type ContextsEntity struct {
 	*StorageEntity
 }
 
-// Get Load a context from file
+// Get Load a context from file. The documents of a directory context are
+// joined with headers, an indexed one is searched instead and has no content.
 func (o *ContextsEntity) Get(name string) (ret *Context, err error) {
+	if o.IsDocuments(name) {
+		ret = &Context{Name: name, Dir: o.BuildFilePathByName(name), Indexed: o.IsIndexed(name)}
+		if !ret.Indexed {
+			ret.Content, err = joinDocuments(ret.Dir)
+		}
+		return
+	}
+
 	var content []byte
 	if content, err = o.Load(name); err != nil {
 		return
 	}
 
-	ret = &Context{Name: name, Content: string(content)}
+	ret = newContext(name, string(content))
+	return
+}
+
+// contextMetadata is the optional YAML front matter of a context file
+type contextMetadata struct {
+	Template  bool                   `yaml:"template"`
+	Variables []template.VariableDef `yaml:"variables"`
+}
+
+// newContext creates a context from its file content. Contexts are sent as
+// they are unless their front matter sets template: true or declares
+// variables; front matter that is not valid YAML is kept as text.
+func newContext(name string, content string) (ret *Context) {
+	ret = &Context{Name: name, Content: content}
+
+	frontMatter, body, ok := splitFrontMatter(content)
+	if !ok {
+		return
+	}
+	var metadata contextMetadata
+	if err := yaml.Unmarshal([]byte(frontMatter), &metadata); err != nil {
+		return
+	}
+	ret.Content = body
+	ret.Template = metadata.Template || len(metadata.Variables) > 0
+	ret.Variables = metadata.Variables
 	return
 }
 
+// IsIndexed reports whether the document context has a vector store
+func (o *ContextsEntity) IsIndexed(name string) bool {
+	_, err := os.Stat(filepath.Join(o.BuildFilePathByName(name), DocumentIndexFileName))
+	return err == nil
+}
+
 func (o *ContextsEntity) PrintContext(name string) (err error) {
 	var context *Context
 	if context, err = o.Get(name); err != nil {
 		return
 	}
-	fmt.Println(context.Content)
+	if !context.Indexed {
+		fmt.Println(context.Content)
+		return
+	}
+
+	var documents map[string][]byte
+	if documents, err = readDocuments(context.Dir); err != nil {
+		return
+	}
+	paths := sortedPaths(documents)
+	fmt.Printf("Indexed document context with %d documents:\n%s\n", len(paths), strings.Join(paths, "\n"))
 	return
 }
 
 type Context struct {
 	Name    string
 	Content string
+	// Dir is the directory of a document context
+	Dir string
+	// Indexed document contexts are searched for the user input
+	Indexed bool
+	// Template contexts are applied with the pattern variables
+	Template  bool
+	Variables []template.VariableDef
+}
+
+// joinDocuments returns the documents of the directory, each under a header
+// with its path
+func joinDocuments(dir string) (ret string, err error) {
+	var documents map[string][]byte
+	if documents, err = readDocuments(dir); err != nil {
+		return
+	}
+
+	var parts []string
+	for _, path := range sortedPaths(documents) {
+		parts = append(parts, fmt.Sprintf("## File: %s\n\n%s", path, strings.TrimSpace(string(documents[path]))))
+	}
+	ret = strings.Join(parts, "\n\n")
+	return
+}
+
+func sortedPaths(documents map[string][]byte) (ret []string) {
+	ret = make([]string, 0, len(documents))
+	for path := range documents {
+		ret = append(ret, path)
+	}
+	sort.Strings(ret)
+	return
 }
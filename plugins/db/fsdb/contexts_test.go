@@ -3,6 +3,7 @@ package fsdb
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -23,7 +24,30 @@ func TestContexts_GetContext(t *testing.T) {
 		t.Fatalf("failed to get context: %v", err)
 	}
 	expectedContext := &Context{Name: contextName, Content: contextContent}
-	if *context != *expectedContext {
+	if !reflect.DeepEqual(context, expectedContext) {
 		t.Errorf("expected %v, got %v", expectedContext, context)
 	}
 }
+
+func TestNewContextFrontMatter(t *testing.T) {
+	plain := newContext("plain", "{{ literal }} braces")
+	if plain.Template || plain.Content != "{{ literal }} braces" {
+		t.Errorf("unexpected plain context %+v", plain)
+	}
+
+	templated := newContext("team", "---\ntemplate: true\n---\nTeam {{team}}")
+	if !templated.Template || templated.Content != "Team {{team}}" {
+		t.Errorf("unexpected template context %+v", templated)
+	}
+
+	declared := newContext("team", "---\nvariables:\n  - name: team\n---\nTeam {{team}}")
+	if !declared.Template || len(declared.Variables) != 1 || declared.Variables[0].Name != "team" {
+		t.Errorf("unexpected context with variables %+v", declared)
+	}
+
+	// a leading rule that is not front matter stays part of the text
+	rule := "---\n: not yaml [\n---\ntext"
+	if ruled := newContext("rule", rule); ruled.Template || ruled.Content != rule {
+		t.Errorf("unexpected context %+v", ruled)
+	}
+}
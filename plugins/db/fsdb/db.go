@@ -19,13 +19,15 @@ func NewDb(dir string) (db *Db) {
 		StorageEntity:          &StorageEntity{Label: "Patterns", Dir: db.FilePath("patterns"), ItemIsDir: true},
 		SystemPatternFile:      "system.md",
 		UniquePatternsFilePath: db.FilePath("unique_patterns.txt"),
+		IndexFilePath:          db.FilePath("pattern_index.json"),
+		DescriptionsFilePath:   db.FilePath("pattern_descriptions.json"),
 	}
 
 	db.Sessions = &SessionsEntity{
 		&StorageEntity{Label: "Sessions", Dir: db.FilePath("sessions"), FileExtension: ".json"}}
 
 	db.Contexts = &ContextsEntity{
-		&StorageEntity{Label: "Contexts", Dir: db.FilePath("contexts")}}
+		&StorageEntity{Label: "Contexts", Dir: db.FilePath("contexts"), ItemMayBeDir: true}}
 
 	return
 }
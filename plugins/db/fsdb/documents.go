@@ -0,0 +1,317 @@
+package fsdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// A document context is a directory of documents. Once indexed, its chunks
+// are embedded into a vector store file in the directory and only the chunks
+// relevant to the user input are sent to the model.
+const (
+	DocumentIndexFileName = ".fabric_index.json"
+	DocumentChunkSize     = 1500 // characters
+	DocumentChunkOverlap  = 200
+	DefaultDocumentTopK   = 5
+
+	documentEmbedBatchSize = 64
+)
+
+// EmbedFunc returns the embedding vectors of the inputs in their order
+type EmbedFunc func(ctx context.Context, inputs []string) ([][]float32, error)
+
+// DocumentIndex is the vector store of a document context
+type DocumentIndex struct {
+	Model string                  `json:"model"`
+	Files map[string]*IndexedFile `json:"files"`
+}
+
+// IndexedFile holds the chunks of a document, the hash of its content
+// decides whether it has to be indexed again
+type IndexedFile struct {
+	Hash   string           `json:"hash"`
+	Chunks []*DocumentChunk `json:"chunks"`
+}
+
+type DocumentChunk struct {
+	Text   string    `json:"text"`
+	Vector []float32 `json:"vector"`
+}
+
+// RetrievedChunk is a chunk found for a query
+type RetrievedChunk struct {
+	Source string
+	Text   string
+	Score  float64
+}
+
+// IsDocuments reports whether the context is a directory of documents
+func (o *ContextsEntity) IsDocuments(name string) bool {
+	info, err := os.Stat(o.BuildFilePathByName(name))
+	return err == nil && info.IsDir()
+}
+
+// IndexDocuments updates the vector store of a document context with the
+// embedding model. Only new and changed documents are embedded, unless the
+// model changed. It returns the paths of the documents that were embedded.
+func (o *ContextsEntity) IndexDocuments(ctx context.Context, name string, model string, embed EmbedFunc) (ret *DocumentIndex, updated []string, err error) {
+	dir := o.BuildFilePathByName(name)
+
+	var documents map[string][]byte
+	if documents, err = readDocuments(dir); err != nil {
+		return
+	}
+
+	ret, updated, err = updateDocumentIndex(ctx, filepath.Join(dir, DocumentIndexFileName), model, documents, embed)
+	return
+}
+
+// updateDocumentIndex embeds the new and changed documents into the vector
+// store file and drops the removed ones
+func updateDocumentIndex(ctx context.Context, indexPath string, model string, documents map[string][]byte, embed EmbedFunc) (ret *DocumentIndex, updated []string, err error) {
+	if ret, err = loadDocumentIndex(indexPath); err != nil {
+		return
+	}
+	if ret.Model != model {
+		ret = &DocumentIndex{Model: model, Files: map[string]*IndexedFile{}}
+	}
+
+	for path := range ret.Files {
+		if _, ok := documents[path]; !ok {
+			delete(ret.Files, path)
+			updated = append(updated, path)
+		}
+	}
+
+	// save the documents indexed so far, also when embedding fails
+	defer func() {
+		if len(updated) > 0 {
+			if saveErr := saveDocumentIndex(indexPath, ret); saveErr != nil && err == nil {
+				err = saveErr
+			}
+		}
+	}()
+
+	for _, path := range sortedPaths(documents) {
+		sum := sha256.Sum256(documents[path])
+		hash := hex.EncodeToString(sum[:])
+		if indexed, ok := ret.Files[path]; ok && indexed.Hash == hash {
+			continue
+		}
+
+		var chunks []*DocumentChunk
+		if chunks, err = embedChunks(ctx, ChunkText(string(documents[path]), DocumentChunkSize, DocumentChunkOverlap), embed); err != nil {
+			err = fmt.Errorf("could not index %s: %w", path, err)
+			return
+		}
+		ret.Files[path] = &IndexedFile{Hash: hash, Chunks: chunks}
+		updated = append(updated, path)
+	}
+	return
+}
+
+// Retrieve indexes the document context and returns the topK chunks most
+// similar to the query
+func (o *ContextsEntity) Retrieve(ctx context.Context, name string, query string, topK int, model string, embed EmbedFunc) (ret []*RetrievedChunk, err error) {
+	var index *DocumentIndex
+	if index, _, err = o.IndexDocuments(ctx, name, model, embed); err != nil {
+		return
+	}
+
+	var vectors [][]float32
+	if vectors, err = embed(ctx, []string{query}); err != nil {
+		return
+	}
+	if len(vectors) != 1 {
+		err = fmt.Errorf("got %d embeddings for the query", len(vectors))
+		return
+	}
+
+	ret = index.Search(vectors[0], topK)
+	return
+}
+
+// Search returns the topK chunks most similar to the vector, all chunks when
+// topK is 0
+func (o *DocumentIndex) Search(vector []float32, topK int) (ret []*RetrievedChunk) {
+	for path, file := range o.Files {
+		for _, chunk := range file.Chunks {
+			ret = append(ret, &RetrievedChunk{Source: path, Text: chunk.Text, Score: cosineSimilarity(vector, chunk.Vector)})
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Score != ret[j].Score {
+			return ret[i].Score > ret[j].Score
+		}
+		return ret[i].Source < ret[j].Source
+	})
+	if topK > 0 && len(ret) > topK {
+		ret = ret[:topK]
+	}
+	return
+}
+
+// ChunkText splits text into chunks of at most size characters. Paragraphs
+// are kept together when they fit, longer ones are split with overlap.
+func ChunkText(text string, size int, overlap int) (ret []string) {
+	var current strings.Builder
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			ret = append(ret, chunk)
+		}
+		current.Reset()
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+
+		runes := []rune(paragraph)
+		if len(runes) > size {
+			flush()
+			for start := 0; start < len(runes); start += size - overlap {
+				end := min(start+size, len(runes))
+				ret = append(ret, string(runes[start:end]))
+				if end == len(runes) {
+					break
+				}
+			}
+			continue
+		}
+
+		if current.Len() > 0 && utf8.RuneCountInString(current.String())+2+len(runes) > size {
+			flush()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(paragraph)
+	}
+	flush()
+	return
+}
+
+func embedChunks(ctx context.Context, texts []string, embed EmbedFunc) (ret []*DocumentChunk, err error) {
+	for start := 0; start < len(texts); start += documentEmbedBatchSize {
+		batch := texts[start:min(start+documentEmbedBatchSize, len(texts))]
+
+		var vectors [][]float32
+		if vectors, err = embed(ctx, batch); err != nil {
+			return
+		}
+		if len(vectors) != len(batch) {
+			err = fmt.Errorf("got %d embeddings for %d chunks", len(vectors), len(batch))
+			return
+		}
+		for i, text := range batch {
+			ret = append(ret, &DocumentChunk{Text: text, Vector: vectors[i]})
+		}
+	}
+	return
+}
+
+// readDocuments returns the text documents of the directory by their slash
+// separated relative paths, hidden and binary files are skipped
+func readDocuments(dir string) (ret map[string][]byte, err error) {
+	ret = map[string][]byte{}
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if path != dir && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		content, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		if !utf8.Valid(content) || bytes.IndexByte(content, 0) >= 0 {
+			return nil
+		}
+
+		rel, relErr := filepath.Rel(dir, path)
+		if relErr != nil {
+			return relErr
+		}
+		ret[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	return
+}
+
+func loadDocumentIndex(path string) (ret *DocumentIndex, err error) {
+	ret = &DocumentIndex{Files: map[string]*IndexedFile{}}
+
+	var content []byte
+	if content, err = os.ReadFile(path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+
+	if err = json.Unmarshal(content, ret); err != nil {
+		err = fmt.Errorf("could not unmarshal %s: %v", path, err)
+		return
+	}
+	if ret.Files == nil {
+		ret.Files = map[string]*IndexedFile{}
+	}
+	return
+}
+
+func saveDocumentIndex(path string, index *DocumentIndex) (err error) {
+	var content []byte
+	if content, err = json.Marshal(index); err != nil {
+		err = fmt.Errorf("could not marshal %s: %v", path, err)
+		return
+	}
+
+	tmpPath := path + ".tmp"
+	if err = os.WriteFile(tmpPath, content, 0644); err != nil {
+		err = fmt.Errorf("could not save %s: %v", path, err)
+		return
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		err = fmt.Errorf("could not save %s: %v", path, err)
+	}
+	return
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,138 @@
+package fsdb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// keywordEmbed embeds texts by counting the keywords they contain
+func keywordEmbed(calls *[]string) EmbedFunc {
+	keywords := []string{"cat", "dog", "fish"}
+	return func(_ context.Context, inputs []string) (ret [][]float32, err error) {
+		for _, input := range inputs {
+			*calls = append(*calls, input)
+			vector := make([]float32, len(keywords))
+			for i, keyword := range keywords {
+				vector[i] = float32(strings.Count(input, keyword))
+			}
+			ret = append(ret, vector)
+		}
+		return
+	}
+}
+
+func TestChunkText(t *testing.T) {
+	testCases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "paragraphs fit", text: "aaa\n\nbbb\n\n\nccc", want: []string{"aaa\n\nbbb", "ccc"}},
+		{name: "long paragraph", text: "abcdefghijkl", want: []string{"abcdefgh", "ghijkl"}},
+		{name: "empty", text: " \n\n ", want: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ChunkText(tc.text, 8, 2)
+			if strings.Join(got, "|") != strings.Join(tc.want, "|") || len(got) != len(tc.want) {
+				t.Errorf("ChunkText() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIndexDocuments(t *testing.T) {
+	dir := t.TempDir()
+	contexts := &ContextsEntity{StorageEntity: &StorageEntity{Dir: dir, ItemMayBeDir: true}}
+	docs := filepath.Join(dir, "pets")
+	if err := os.MkdirAll(filepath.Join(docs, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(docs, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("cats.md", "the cat sleeps")
+	write("sub/dogs.md", "a dog barks")
+	write(".hidden", "fish")
+	write("binary.bin", "\x00\x01")
+
+	if !contexts.IsDocuments("pets") {
+		t.Fatal("expected a document context")
+	}
+
+	var calls []string
+	index, updated, err := contexts.IndexDocuments(context.Background(), "pets", "model-a", keywordEmbed(&calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Files) != 2 || len(updated) != 2 || len(calls) != 2 {
+		t.Fatalf("indexed %v, updated %v, embedded %v", index.Files, updated, calls)
+	}
+	if _, err = os.Stat(filepath.Join(docs, DocumentIndexFileName)); err != nil {
+		t.Fatalf("index not saved: %v", err)
+	}
+
+	// only changed documents are embedded again
+	calls = nil
+	write("cats.md", "the cat sleeps, the cat purrs")
+	if err = os.Remove(filepath.Join(docs, "sub", "dogs.md")); err != nil {
+		t.Fatal(err)
+	}
+	if index, updated, err = contexts.IndexDocuments(context.Background(), "pets", "model-a", keywordEmbed(&calls)); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Files) != 1 || len(updated) != 2 || len(calls) != 1 {
+		t.Fatalf("indexed %v, updated %v, embedded %v", index.Files, updated, calls)
+	}
+
+	// a new model indexes everything again
+	calls = nil
+	if _, _, err = contexts.IndexDocuments(context.Background(), "pets", "model-b", keywordEmbed(&calls)); err != nil || len(calls) != 1 {
+		t.Fatalf("embedded %v, %v", calls, err)
+	}
+
+	failing := func(context.Context, []string) ([][]float32, error) { return nil, errors.New("offline") }
+	write("fish.md", "fish")
+	if _, _, err = contexts.IndexDocuments(context.Background(), "pets", "model-b", failing); err == nil {
+		t.Error("expected the embedding error")
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	dir := t.TempDir()
+	contexts := &ContextsEntity{StorageEntity: &StorageEntity{Dir: dir, ItemMayBeDir: true}}
+	docs := filepath.Join(dir, "pets")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"cats.md": "cat cat", "dogs.md": "dog", "fish.md": "fish and a cat"} {
+		if err := os.WriteFile(filepath.Join(docs, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var calls []string
+	chunks, err := contexts.Retrieve(context.Background(), "pets", "where is the cat?", 2, "model", keywordEmbed(&calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[0].Source != "cats.md" || chunks[1].Source != "fish.md" {
+		t.Errorf("unexpected chunks %+v", chunks)
+	}
+
+	context, err := contexts.Get("pets")
+	if err != nil || context.Dir != docs {
+		t.Errorf("Get() = %+v, %v", context, err)
+	}
+	names, err := contexts.GetNames()
+	if err != nil || len(names) != 1 {
+		t.Errorf("GetNames() = %v, %v", names, err)
+	}
+}
@@ -1,6 +1,8 @@
 package fsdb
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,14 +10,23 @@ import (
 
 	"github.com/danielmiessler/fabric/common"
 	"github.com/danielmiessler/fabric/plugins/template"
+	"gopkg.in/yaml.v3"
 )
 
-const inputSentinel = "__FABRIC_INPUT_SENTINEL_TOKEN__"
+const frontMatterDelimiter = "---"
 
 type PatternsEntity struct {
 	*StorageEntity
 	SystemPatternFile      string
 	UniquePatternsFilePath string
+	// IndexFilePath is the vector store of the patterns, used for suggestions
+	IndexFilePath string
+	// DescriptionsFilePath holds the curated pattern descriptions downloaded
+	// with the patterns
+	DescriptionsFilePath string
+
+	// VariablePrompter is asked for missing pattern variables, if set
+	VariablePrompter template.VariablePrompter
 }
 
 // Pattern represents a single pattern with its metadata
@@ -23,6 +34,13 @@ type Pattern struct {
 	Name        string
 	Description string
 	Pattern     string
+	Variables   []template.VariableDef
+}
+
+// patternMetadata is the optional YAML front matter of a pattern file
+type patternMetadata struct {
+	Description string                 `yaml:"description"`
+	Variables   []template.VariableDef `yaml:"variables"`
 }
 
 // GetApplyVariables main entry point for getting patterns from any source
@@ -61,29 +79,26 @@ func (o *PatternsEntity) GetApplyVariables(
 func (o *PatternsEntity) applyVariables(
 	pattern *Pattern, variables map[string]string, input string) (err error) {
 
+	// Validate variables against the pattern declarations, apply defaults
+	// and ask for missing ones
+	if variables, err = template.ResolveVariables(
+		pattern.Pattern, pattern.Variables, variables, o.VariablePrompter); err != nil {
+		return
+	}
+
 	// Ensure pattern has an {{input}} placeholder
 	// If not present, append it on a new line
-	if !strings.Contains(pattern.Pattern, "{{input}}") {
+	if !template.UsesInput(pattern.Pattern) {
 		if !strings.HasSuffix(pattern.Pattern, "\n") {
 			pattern.Pattern += "\n"
 		}
 		pattern.Pattern += "{{input}}"
 	}
 
-	// Temporarily replace {{input}} with a sentinel token to protect it
-	// from recursive variable resolution
-	withSentinel := strings.ReplaceAll(pattern.Pattern, "{{input}}", inputSentinel)
-
-	// Process all other template variables in the pattern
-	// At this point, our sentinel ensures {{input}} won't be affected
-	var processed string
-	if processed, err = template.ApplyTemplate(withSentinel, variables, ""); err != nil {
-		return
-	}
-
-	// Finally, replace our sentinel with the actual user input
-	// The input has already been processed for variables if InputHasVars was true
-	pattern.Pattern = strings.ReplaceAll(processed, inputSentinel, input)
+	// The user input is inserted as plain text, template syntax in it is not
+	// evaluated. It has already been processed for variables if InputHasVars
+	// was true.
+	pattern.Pattern, err = template.ApplyTemplate(pattern.Pattern, variables, input)
 	return
 }
 
@@ -96,11 +111,7 @@ func (o *PatternsEntity) getFromDB(name string) (ret *Pattern, err error) {
 		return
 	}
 
-	patternStr := string(pattern)
-	ret = &Pattern{
-		Name:    name,
-		Pattern: patternStr,
-	}
+	ret, err = newPattern(name, string(pattern))
 	return
 }
 
@@ -138,15 +149,137 @@ func (o *PatternsEntity) getFromFile(pathStr string) (pattern *Pattern, err erro
 		err = fmt.Errorf("could not read pattern file %s: %v", pathStr, err)
 		return
 	}
-	pattern = &Pattern{
-		Name:    pathStr,
-		Pattern: string(content),
+	pattern, err = newPattern(pathStr, string(content))
+	return
+}
+
+// newPattern creates a pattern from its file content, extracting the
+// optional YAML front matter with the description and variable declarations
+func newPattern(name string, content string) (ret *Pattern, err error) {
+	ret = &Pattern{Name: name, Pattern: content}
+
+	frontMatter, body, ok := splitFrontMatter(content)
+	if !ok {
+		return
+	}
+
+	var metadata patternMetadata
+	if err = yaml.Unmarshal([]byte(frontMatter), &metadata); err != nil {
+		err = fmt.Errorf("could not parse metadata of pattern %s: %v", name, err)
+		return
 	}
+	ret.Description = metadata.Description
+	ret.Variables = metadata.Variables
+	ret.Pattern = body
 	return
 }
 
+// splitFrontMatter returns the YAML front matter of content and the text
+// after it, ok is false when content has none
+func splitFrontMatter(content string) (frontMatter string, body string, ok bool) {
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, frontMatterDelimiter+"\n") {
+		return
+	}
+	rest := normalized[len(frontMatterDelimiter)+1:]
+	end := strings.Index(rest, "\n"+frontMatterDelimiter+"\n")
+	if end < 0 {
+		return
+	}
+	return rest[:end], strings.TrimLeft(rest[end+len(frontMatterDelimiter)+2:], "\n"), true
+}
+
 // Get required for Storage interface
 func (o *PatternsEntity) Get(name string) (*Pattern, error) {
 	// Use GetPattern with no variables
 	return o.GetApplyVariables(name, nil, "")
 }
+
+// GetAll loads all patterns without applying variables, patterns that cannot
+// be read are skipped. Patterns without a description in their front matter
+// get the one of the descriptions file.
+func (o *PatternsEntity) GetAll() (ret []*Pattern, err error) {
+	var names []string
+	if names, err = o.GetNames(); err != nil {
+		return
+	}
+
+	descriptions := o.loadDescriptions()
+	for _, name := range names {
+		if pattern, getErr := o.getFromDB(name); getErr == nil {
+			if pattern.Description == "" {
+				pattern.Description = descriptions[name]
+			}
+			ret = append(ret, pattern)
+		}
+	}
+	return
+}
+
+// loadDescriptions reads the descriptions file by pattern name, it is
+// optional so errors leave the descriptions empty
+func (o *PatternsEntity) loadDescriptions() (ret map[string]string) {
+	ret = map[string]string{}
+	if o.DescriptionsFilePath == "" {
+		return
+	}
+
+	var content []byte
+	var err error
+	if content, err = os.ReadFile(o.DescriptionsFilePath); err != nil {
+		return
+	}
+	var file struct {
+		Patterns []struct {
+			PatternName string `json:"patternName"`
+			Description string `json:"description"`
+		} `json:"patterns"`
+	}
+	if err = json.Unmarshal(content, &file); err != nil {
+		return
+	}
+	for _, pattern := range file.Patterns {
+		ret[pattern.PatternName] = pattern.Description
+	}
+	return
+}
+
+// IndexPatterns updates the vector store of the patterns with the embedding
+// model, only new and changed patterns are embedded
+func (o *PatternsEntity) IndexPatterns(ctx context.Context, patterns []*Pattern, model string, embed EmbedFunc) (ret *DocumentIndex, err error) {
+	documents := make(map[string][]byte, len(patterns))
+	for _, pattern := range patterns {
+		documents[pattern.Name] = []byte(pattern.SearchText())
+	}
+	ret, _, err = updateDocumentIndex(ctx, o.IndexFilePath, model, documents, embed)
+	return
+}
+
+// SearchText is the text patterns are found by: the name, the description
+// and the prompt
+func (o *Pattern) SearchText() string {
+	return strings.Join([]string{strings.ReplaceAll(o.Name, "_", " "), o.Description, o.Pattern}, "\n\n")
+}
+
+// Summary returns the description of the pattern, or the first sentence of
+// the prompt when it has none
+func (o *Pattern) Summary() string {
+	if o.Description != "" {
+		return o.Description
+	}
+
+	for _, paragraph := range strings.Split(strings.ReplaceAll(o.Pattern, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.Join(strings.Fields(paragraph), " ")
+		if paragraph == "" || strings.HasPrefix(paragraph, "#") {
+			continue
+		}
+		if end := strings.Index(paragraph, ". "); end >= 0 {
+			paragraph = paragraph[:end+1]
+		}
+		if runes := []rune(paragraph); len(runes) > 160 {
+			paragraph = string(runes[:157]) + "..."
+		}
+		return paragraph
+	}
+	return ""
+}
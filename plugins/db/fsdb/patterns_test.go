@@ -98,6 +98,14 @@ func TestGetApplyVariables(t *testing.T) {
 
 	// Create a test pattern
 	createTestPattern(t, entity, "test-pattern", "You are a {{role}}.\n{{input}}")
+	createTestPattern(t, entity, "test-pattern-meta", `---
+variables:
+  - name: role
+    enum: [reviewer, expert]
+    default: reviewer
+---
+You are a {{role}}.
+{{input}}`)
 
 	tests := []struct {
 		name      string
@@ -128,6 +136,18 @@ func TestGetApplyVariables(t *testing.T) {
 			source:  "non-existent",
 			wantErr: true,
 		},
+		{
+			name:   "front matter default applied",
+			source: "test-pattern-meta",
+			input:  "check this code",
+			want:   "You are a reviewer.\ncheck this code",
+		},
+		{
+			name:      "front matter enum violated",
+			source:    "test-pattern-meta",
+			variables: map[string]string{"role": "chef"},
+			wantErr:   true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -144,3 +164,41 @@ func TestGetApplyVariables(t *testing.T) {
 		})
 	}
 }
+
+func TestNewPatternFrontMatter(t *testing.T) {
+	pattern, err := newPattern("meta", "---\ndescription: Test pattern\nvariables:\n  - name: points\n    type: int\n    default: \"3\"\n---\n\n# IDENTITY\n{{points}}")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Test pattern", pattern.Description)
+	assert.Equal(t, "# IDENTITY\n{{points}}", pattern.Pattern)
+	require.Len(t, pattern.Variables, 1)
+	assert.Equal(t, "points", pattern.Variables[0].Name)
+	assert.Equal(t, "int", pattern.Variables[0].Type)
+	require.NotNil(t, pattern.Variables[0].Default)
+	assert.Equal(t, "3", *pattern.Variables[0].Default)
+
+	plain, err := newPattern("plain", "# IDENTITY\n---\ntext")
+	require.NoError(t, err)
+	assert.Equal(t, "# IDENTITY\n---\ntext", plain.Pattern)
+	assert.Empty(t, plain.Variables)
+}
+
+func TestGetAllDescriptions(t *testing.T) {
+	entity, cleanup := setupTestPatternsEntity(t)
+	defer cleanup()
+
+	entity.DescriptionsFilePath = filepath.Join(entity.Dir, "pattern_descriptions.json")
+	require.NoError(t, os.WriteFile(entity.DescriptionsFilePath, []byte(
+		`{"patterns": [{"patternName": "plain", "description": "Curated text."}, {"patternName": "meta", "description": "Ignored."}]}`), 0644))
+	createTestPattern(t, entity, "plain", "# IDENTITY and PURPOSE\n\nYou are a writer.")
+	createTestPattern(t, entity, "meta", "---\ndescription: Front matter.\n---\nYou are a reader.")
+	createTestPattern(t, entity, "other", "You are a thinker.")
+
+	patterns, err := entity.GetAll()
+	require.NoError(t, err)
+	descriptions := map[string]string{}
+	for _, pattern := range patterns {
+		descriptions[pattern.Name] = pattern.Summary()
+	}
+	assert.Equal(t, map[string]string{"plain": "Curated text.", "meta": "Front matter.", "other": "You are a thinker."}, descriptions)
+}
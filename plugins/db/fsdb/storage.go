@@ -14,6 +14,7 @@ type StorageEntity struct {
 	Label         string
 	Dir           string
 	ItemIsDir     bool
+	ItemMayBeDir  bool // list directories besides the files
 	FileExtension string
 }
 
@@ -55,7 +56,11 @@ func (o *StorageEntity) GetNames() (ret []string, err error) {
 			}
 		} else {
 			// Include files, optionally filtering by extension
-			if !fileInfo.IsDir() {
+			if fileInfo.IsDir() {
+				if o.ItemMayBeDir {
+					ret = append(ret, entry.Name())
+				}
+			} else {
 				if o.FileExtension == "" || filepath.Ext(entry.Name()) == o.FileExtension {
 					ret = append(ret, strings.TrimSuffix(entry.Name(), o.FileExtension))
 				}
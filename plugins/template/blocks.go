@@ -0,0 +1,82 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	loopItemVariable  = "this"
+	loopIndexVariable = "@index"
+)
+
+// isTruthy reports whether a variable is set to a value other than
+// empty, "false" or "0"
+func isTruthy(value string, ok bool) bool {
+	if !ok {
+		return false
+	}
+	value = strings.TrimSpace(value)
+	return value != "" && value != "0" && !strings.EqualFold(value, "false")
+}
+
+// splitList turns a variable value into loop items. JSON arrays are used as
+// they are, multi-line values give one item per non-empty line and anything
+// else is split on commas.
+func splitList(value string) (ret []string) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return
+	}
+
+	if strings.HasPrefix(trimmed, "[") {
+		var items []interface{}
+		if err := json.Unmarshal([]byte(trimmed), &items); err == nil {
+			for _, item := range items {
+				if s, ok := item.(string); ok {
+					ret = append(ret, s)
+				} else {
+					data, _ := json.Marshal(item)
+					ret = append(ret, string(data))
+				}
+			}
+			return
+		}
+	}
+
+	separator := ","
+	if strings.Contains(trimmed, "\n") {
+		separator = "\n"
+	}
+	for _, item := range strings.Split(trimmed, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+// splitFilters parses "| upper | trim" into the filter names
+func splitFilters(spec string) (ret []string) {
+	for _, filter := range strings.Split(spec, "|") {
+		if filter = strings.TrimSpace(filter); filter != "" {
+			ret = append(ret, filter)
+		}
+	}
+	return
+}
+
+// applyFilters runs value through the text plugin operations in order
+func applyFilters(value string, filters []string) (string, error) {
+	for _, filter := range filters {
+		if value == "" {
+			break
+		}
+		var err error
+		if value, err = textPlugin.Apply(filter, value); err != nil {
+			return "", fmt.Errorf("filter %s: %w", filter, err)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,174 @@
+package template
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CSVPlugin selects columns and rows of CSV data. The first record is the
+// header; columns are addressed by header name or 1-based index and rows
+// are numbered from 1, not counting the header.
+type CSVPlugin struct{}
+
+// Name returns the template namespace of the plugin
+func (p *CSVPlugin) Name() string {
+	return "csv"
+}
+
+// Description returns a short description of the plugin
+func (p *CSVPlugin) Description() string {
+	return "Select columns and rows of CSV data with a header row"
+}
+
+// Operations lists the supported csv operations
+func (p *CSVPlugin) Operations() []Operation {
+	return []Operation{
+		{Name: "column", Usage: pluginUsage("csv", "column", "COLUMN:DATA"), Description: "Values of a column, one per line"},
+		{Name: "select", Usage: pluginUsage("csv", "select", "COL1,COL2:DATA"), Description: "CSV with only the given columns"},
+		{Name: "rows", Usage: pluginUsage("csv", "rows", "N[-M]:DATA"), Description: "CSV with the header and rows N to M"},
+		{Name: "to-json", Usage: pluginUsage("csv", "to-json", "DATA"), Description: "Array of objects keyed by the header"},
+	}
+}
+
+// Apply executes csv operations
+func (p *CSVPlugin) Apply(operation string, value string) (string, error) {
+	debugf("CSV: operation=%q", operation)
+
+	arg, data := "", value
+	switch operation {
+	case "column", "select", "rows":
+		var ok bool
+		if arg, data, ok = splitArgument(value); !ok || arg == "" {
+			return "", fmt.Errorf("csv: %s requires an argument, e.g. %s", operation, p.usage(operation))
+		}
+	case "to-json":
+	default:
+		return "", fmt.Errorf("csv: unknown operation %q (supported: column, select, rows, to-json)", operation)
+	}
+
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return "", fmt.Errorf("csv: %v", err)
+	}
+	if len(records) == 0 {
+		return "", fmt.Errorf("csv: no header row")
+	}
+	header, rows := records[0], records[1:]
+
+	switch operation {
+	case "column":
+		index, err := columnIndex(header, arg)
+		if err != nil {
+			return "", err
+		}
+		values := make([]string, 0, len(rows))
+		for _, row := range rows {
+			values = append(values, field(row, index))
+		}
+		return strings.Join(values, "\n"), nil
+
+	case "select":
+		var indexes []int
+		for _, name := range strings.Split(arg, ",") {
+			index, err := columnIndex(header, strings.TrimSpace(name))
+			if err != nil {
+				return "", err
+			}
+			indexes = append(indexes, index)
+		}
+		selected := make([][]string, 0, len(records))
+		for _, record := range records {
+			out := make([]string, len(indexes))
+			for i, index := range indexes {
+				out[i] = field(record, index)
+			}
+			selected = append(selected, out)
+		}
+		return writeCSV(selected)
+
+	case "rows":
+		from, to, err := parseRowRange(arg, len(rows))
+		if err != nil {
+			return "", err
+		}
+		return writeCSV(append([][]string{header}, rows[from-1:to]...))
+
+	default:
+		objects := make([]map[string]string, 0, len(rows))
+		for _, row := range rows {
+			object := make(map[string]string, len(header))
+			for i, name := range header {
+				object[name] = field(row, i)
+			}
+			objects = append(objects, object)
+		}
+		out, err := json.MarshalIndent(objects, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("csv: %v", err)
+		}
+		return string(out), nil
+	}
+}
+
+func (p *CSVPlugin) usage(operation string) string {
+	for _, op := range p.Operations() {
+		if op.Name == operation {
+			return op.Usage
+		}
+	}
+	return operation
+}
+
+// columnIndex finds a column by header name, falling back to a 1-based index
+func columnIndex(header []string, column string) (int, error) {
+	for i, name := range header {
+		if name == column {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(column); err == nil && n >= 1 && n <= len(header) {
+		return n - 1, nil
+	}
+	return 0, fmt.Errorf("csv: unknown column %q", column)
+}
+
+// parseRowRange parses "N" or "N-M" into 1-based inclusive bounds
+func parseRowRange(spec string, count int) (from int, to int, err error) {
+	fromStr, toStr, isRange := strings.Cut(spec, "-")
+	if from, err = strconv.Atoi(strings.TrimSpace(fromStr)); err != nil {
+		return 0, 0, fmt.Errorf("csv: invalid row %q", spec)
+	}
+	to = from
+	if isRange {
+		if to, err = strconv.Atoi(strings.TrimSpace(toStr)); err != nil {
+			return 0, 0, fmt.Errorf("csv: invalid row range %q", spec)
+		}
+	}
+	if from < 1 || to < from || to > count {
+		return 0, 0, fmt.Errorf("csv: rows %s out of range (1-%d)", spec, count)
+	}
+	return
+}
+
+// field returns the value at index, or an empty string for short rows
+func field(record []string, index int) string {
+	if index < len(record) {
+		return record[index]
+	}
+	return ""
+}
+
+func writeCSV(records [][]string) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(records); err != nil {
+		return "", fmt.Errorf("csv: %v", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,46 @@
+package template
+
+import "testing"
+
+func TestCSVPlugin(t *testing.T) {
+	plugin := &CSVPlugin{}
+	data := "name,lang,stars\nfabric,go,100\n\"tool, the\",python,5\nshort,rust"
+
+	tests := []struct {
+		name      string
+		operation string
+		value     string
+		want      string
+		wantErr   bool
+	}{
+		{name: "column by name", operation: "column", value: "name:" + data, want: "fabric\ntool, the\nshort"},
+		{name: "column by index", operation: "column", value: "3:" + data, want: "100\n5\n"},
+		{name: "unknown column", operation: "column", value: "owner:" + data, wantErr: true},
+		{name: "select columns", operation: "select", value: "lang,name:" + data, want: "lang,name\ngo,fabric\npython,\"tool, the\"\nrust,short"},
+		{name: "single row", operation: "rows", value: "2:" + data, want: "name,lang,stars\n\"tool, the\",python,5"},
+		{name: "row range", operation: "rows", value: "1-2:" + data, want: "name,lang,stars\nfabric,go,100\n\"tool, the\",python,5"},
+		{name: "row out of range", operation: "rows", value: "4:" + data, wantErr: true},
+		{name: "invalid row", operation: "rows", value: "first:" + data, wantErr: true},
+		{
+			name:      "to json",
+			operation: "to-json",
+			value:     "a,b\n1,2",
+			want:      "[\n  {\n    \"a\": \"1\",\n    \"b\": \"2\"\n  }\n]",
+		},
+		{name: "empty data", operation: "to-json", value: "", wantErr: true},
+		{name: "missing argument", operation: "column", value: data, wantErr: true},
+		{name: "unknown operation", operation: "sum", value: data, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plugin.Apply(tt.operation, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CSVPlugin.Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("CSVPlugin.Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,6 +10,36 @@ import (
 // DateTimePlugin handles time and date operations
 type DateTimePlugin struct{}
 
+// Name returns the template namespace of the plugin
+func (p *DateTimePlugin) Name() string {
+	return "datetime"
+}
+
+// Description returns a short description of the plugin
+func (p *DateTimePlugin) Description() string {
+	return "Time and date operations"
+}
+
+// Operations lists the supported datetime operations
+func (p *DateTimePlugin) Operations() []Operation {
+	return []Operation{
+		{Name: "now", Usage: pluginUsage("datetime", "now", ""), Description: "Current time in RFC3339"},
+		{Name: "time", Usage: pluginUsage("datetime", "time", ""), Description: "Current time as HH:MM:SS"},
+		{Name: "unix", Usage: pluginUsage("datetime", "unix", ""), Description: "Current Unix timestamp"},
+		{Name: "startofhour", Usage: pluginUsage("datetime", "startofhour", ""), Description: "Start of the current hour in RFC3339"},
+		{Name: "endofhour", Usage: pluginUsage("datetime", "endofhour", ""), Description: "End of the current hour in RFC3339"},
+		{Name: "today", Usage: pluginUsage("datetime", "today", ""), Description: "Current date as YYYY-MM-DD"},
+		{Name: "full", Usage: pluginUsage("datetime", "full", ""), Description: "Current date as Monday, January 2, 2006"},
+		{Name: "month", Usage: pluginUsage("datetime", "month", ""), Description: "Name of the current month"},
+		{Name: "year", Usage: pluginUsage("datetime", "year", ""), Description: "Current year"},
+		{Name: "startofweek", Usage: pluginUsage("datetime", "startofweek", ""), Description: "First day of the current week"},
+		{Name: "endofweek", Usage: pluginUsage("datetime", "endofweek", ""), Description: "Last day of the current week"},
+		{Name: "startofmonth", Usage: pluginUsage("datetime", "startofmonth", ""), Description: "First day of the current month"},
+		{Name: "endofmonth", Usage: pluginUsage("datetime", "endofmonth", ""), Description: "Last day of the current month"},
+		{Name: "rel", Usage: pluginUsage("datetime", "rel", "-1d"), Description: "Relative time or date (h, m, d, w, m, y units)"},
+	}
+}
+
 // Apply executes datetime operations with the following formats:
 // Time: now (RFC3339), time (HH:MM:SS), unix (timestamp)
 // Hour: startofhour, endofhour
@@ -25,11 +25,20 @@ func NewExtensionExecutor(registry *ExtensionRegistry) *ExtensionExecutor {
 	}
 }
 
+// DefaultExtensionTimeout applies to extensions without a timeout
+const DefaultExtensionTimeout = 30 * time.Second
+
+// DefaultExtensionEnv are the variables of fabric's environment every
+// extension receives; everything else needs to be listed in env_allow
+var DefaultExtensionEnv = []string{
+	"PATH", "HOME", "USER", "LANG", "LC_ALL", "TMPDIR", "TZ",
+	"SYSTEMROOT", "TEMP", "TMP", // required by many programs on Windows
+}
+
 // Execute runs an extension with the given operation and value string
 // name: the registered name of the extension
 // operation: the operation to perform
 // value: the input value(s) for the operation
-// In extension_executor.go
 func (e *ExtensionExecutor) Execute(name, operation, value string) (string, error) {
 	// Get and verify extension from registry
 	ext, err := e.registry.GetExtension(name)
@@ -37,33 +46,112 @@ func (e *ExtensionExecutor) Execute(name, operation, value string) (string, erro
 		return "", fmt.Errorf("failed to get extension: %w", err)
 	}
 
-	// Format the command using our template system
-	cmdStr, err := e.formatCommand(ext, operation, value)
+	// {{ext:name:op:arg#field}} selects a named output of a JSON response
+	field := ""
+	jsonOutput := ext.GetOutputFormat() == ExtensionFormatJSON
+	if jsonOutput {
+		var valueField string
+		operation, field = splitOutputField(operation)
+		if value, valueField = splitOutputField(value); field == "" {
+			field = valueField
+		}
+	}
+
+	timeout, err := ext.GetTimeout()
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var ret string
+	if ext.IsHTTP() {
+		ret, err = e.executeHTTP(ctx, ext, operation, value)
+	} else {
+		var cmd *exec.Cmd
+		if cmd, err = e.buildCommand(ctx, ext, operation, value); err != nil {
+			return "", err
+		}
+		cmd.Env = ext.buildEnv(os.Environ())
+		// do not wait for grandchildren holding the output open after a timeout
+		cmd.WaitDelay = time.Second
+
+		// Execute based on output method
+		if ext.GetOutputMethod() == "file" {
+			ret, err = e.executeWithFile(cmd, ext)
+		} else {
+			ret, err = e.executeStdout(cmd, ext)
+		}
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("execution timed out after %v", timeout)
+	}
+	if jsonOutput {
+		return e.decodeResponse(ext, ret, err, field)
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to format command: %w", err)
+		return "", err
 	}
+	return ret, nil
+}
 
-	// Split the command string into command and arguments
-	cmdParts := strings.Fields(cmdStr)
-	if len(cmdParts) < 1 {
-		return "", fmt.Errorf("empty command after formatting")
+// buildCommand creates the command of an operation. Operations with args run
+// the executable directly, cmd_template operations run through sh -c with
+// quoted values.
+func (e *ExtensionExecutor) buildCommand(ctx context.Context, ext *ExtensionDefinition, operation string, value string) (*exec.Cmd, error) {
+	opConfig, exists := ext.Operations[operation]
+	if !exists {
+		return nil, fmt.Errorf("operation %s not found for extension %s", operation, ext.Name)
 	}
 
-	// Create command with the Executable and formatted arguments
-	cmd := exec.Command("sh", "-c", cmdStr)
-	//cmd := exec.Command(cmdParts[0], cmdParts[1:]...)
+	var cmd *exec.Cmd
+	if len(opConfig.Args) > 0 {
+		vars := e.templateVariables(ext, operation, value)
+		args := make([]string, 0, len(opConfig.Args))
+		for _, arg := range opConfig.Args {
+			formatted, err := ApplyTemplate(arg, vars, "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to format argument %q: %w", arg, err)
+			}
+			args = append(args, formatted)
+		}
+		cmd = exec.CommandContext(ctx, ext.Executable, args...)
+	} else {
+		// Format the command using our template system
+		cmdStr, err := e.formatCommand(ext, operation, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format command: %w", err)
+		}
+		if strings.TrimSpace(cmdStr) == "" {
+			return nil, fmt.Errorf("empty command after formatting")
+		}
+		cmd = exec.CommandContext(ctx, "sh", "-c", cmdStr)
+	}
 
-	// Set up environment if specified
-	if len(ext.Env) > 0 {
-		cmd.Env = append(os.Environ(), ext.Env...)
+	if opConfig.Stdin {
+		cmd.Stdin = strings.NewReader(value)
 	}
+	debugf("Executing command: %s\n", cmd.String())
+	return cmd, nil
+}
 
-	// Execute based on output method
-	outputMethod := ext.GetOutputMethod()
-	if outputMethod == "file" {
-		return e.executeWithFile(cmd, ext)
+// buildEnv keeps the allowed variables of environ and adds the variables
+// set by the extension
+func (ext *ExtensionDefinition) buildEnv(environ []string) []string {
+	allowed := append(append([]string{}, DefaultExtensionEnv...), ext.EnvAllow...)
+	var ret []string
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		for _, pattern := range allowed {
+			// names are compared case-insensitively for Windows
+			prefix, isPrefix := strings.CutSuffix(strings.ToUpper(pattern), "*")
+			if upper := strings.ToUpper(name); upper == prefix || (isPrefix && strings.HasPrefix(upper, prefix)) {
+				ret = append(ret, entry)
+				break
+			}
+		}
 	}
-	return e.executeStdout(cmd, ext)
+	return append(ret, ext.Env...)
 }
 
 // formatCommand uses fabric's template system to format the command
@@ -75,6 +163,90 @@ func (e *ExtensionExecutor) formatCommand(ext *ExtensionDefinition, operation st
 		return "", fmt.Errorf("operation %s not found for extension %s", operation, ext.Name)
 	}
 
+	return shellTemplate(opConfig.CmdTemplate, e.templateVariables(ext, operation, value))
+}
+
+// shellTemplate formats a command line for sh -c. Every value is quoted for
+// the place it is inserted at, unquoted, in '...' or in "...", so it is one
+// word of the command whatever it contains.
+func shellTemplate(tmpl string, vars map[string]string) (string, error) {
+	// the template is formatted with markers that are replaced once the
+	// quoting around them is known
+	markers := make(map[string]string, len(vars))
+	values := make(map[string]string, len(vars))
+	for name, value := range vars {
+		marker := fmt.Sprintf("\x00%d\x00", len(markers))
+		markers[name] = marker
+		values[marker] = value
+	}
+	formatted, err := ApplyTemplate(tmpl, markers, "")
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	var quote byte // 0, '\'' or '"'
+	substitution := 0
+	backtick := false
+	for i := 0; i < len(formatted); i++ {
+		c := formatted[i]
+		switch {
+		case c == 0:
+			end := strings.IndexByte(formatted[i+1:], 0)
+			if end < 0 {
+				return "", fmt.Errorf("invalid command template")
+			}
+			marker := formatted[i : i+end+2]
+			if substitution > 0 || backtick {
+				return "", fmt.Errorf("template values cannot be used inside $(...) or backticks, use args instead")
+			}
+			sb.WriteString(shellQuote(values[marker], quote))
+			i += end + 1
+			continue
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			sb.WriteByte(c)
+			if i+1 < len(formatted) && formatted[i+1] != 0 {
+				i++
+				sb.WriteByte(formatted[i])
+			}
+			continue
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case quote == '"' && c == '"':
+			quote = 0
+		case c == '`':
+			backtick = !backtick
+		case c == '$' && i+1 < len(formatted) && formatted[i+1] == '(':
+			substitution++
+		case c == ')' && substitution > 0:
+			substitution--
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), nil
+}
+
+// shellQuote quotes a value for the quoting it is inserted in
+func shellQuote(value string, quote byte) string {
+	switch quote {
+	case '\'':
+		return strings.ReplaceAll(value, "'", `'\''`)
+	case '"':
+		return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value)
+	}
+	if value == "" {
+		// an empty value adds no argument, as before values were quoted
+		return ""
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// templateVariables are the variables available in commands and arguments
+func (e *ExtensionExecutor) templateVariables(ext *ExtensionDefinition, operation string, value string) map[string]string {
 	vars := make(map[string]string)
 	vars["executable"] = ext.Executable
 	vars["operation"] = operation
@@ -85,22 +257,19 @@ func (e *ExtensionExecutor) formatCommand(ext *ExtensionDefinition, operation st
 	for i, val := range values {
 		vars[fmt.Sprintf("%d", i+1)] = val
 	}
-
-	return ApplyTemplate(opConfig.CmdTemplate, vars, "")
+	return vars
 }
 
-// executeStdout runs the command and captures its stdout
+// executeStdout runs the command and captures its stdout, which is also
+// returned when the command fails
 func (e *ExtensionExecutor) executeStdout(cmd *exec.Cmd, ext *ExtensionDefinition) (string, error) {
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	//debug output
-	fmt.Printf("Executing command: %s\n", cmd.String())
-
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("execution failed: %w\nstderr: %s", err, stderr.String())
+		return stdout.String(), fmt.Errorf("execution failed: %w\nstderr: %s", err, stderr.String())
 	}
 
 	return stdout.String(), nil
@@ -108,22 +277,6 @@ func (e *ExtensionExecutor) executeStdout(cmd *exec.Cmd, ext *ExtensionDefinitio
 
 // executeWithFile runs the command and handles file-based output
 func (e *ExtensionExecutor) executeWithFile(cmd *exec.Cmd, ext *ExtensionDefinition) (string, error) {
-	// Parse timeout - this is now a first-class field
-	timeout, err := time.ParseDuration(ext.Timeout)
-	if err != nil {
-		return "", fmt.Errorf("invalid timeout format: %w", err)
-	}
-
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	// Store the original environment
-	originalEnv := cmd.Env
-	// Create a new command with context. This might reset Env, depending on the Go version.
-	cmd = exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
-	// Restore the environment variables explicitly
-	cmd.Env = originalEnv
-
 	fileConfig := ext.GetFileConfig()
 	if fileConfig == nil {
 		return "", fmt.Errorf("no file configuration found")
@@ -151,9 +304,6 @@ func (e *ExtensionExecutor) executeWithFile(cmd *exec.Cmd, ext *ExtensionDefinit
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return "", fmt.Errorf("execution timed out after %v", timeout)
-		}
 		return "", fmt.Errorf("execution failed: %w\nerr: %s", err, stderr.String())
 	}
 
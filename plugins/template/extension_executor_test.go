@@ -358,3 +358,130 @@ config:
 		}
 	})
 }
+
+func TestExtensionExecutorSafety(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testScript := filepath.Join(tmpDir, "safe-script.sh")
+	scriptContent := `#!/bin/sh
+case "$1" in
+    "args")
+        shift
+        for arg in "$@"; do echo "[$arg]"; done
+        ;;
+    "stdin")
+        echo "got: $(cat)"
+        ;;
+    "env")
+        echo "allowed=$FABRIC_TEST_ALLOWED secret=$FABRIC_TEST_SECRET set=$FABRIC_TEST_SET"
+        ;;
+    "sleep")
+        sleep 5
+        ;;
+esac`
+	if err := os.WriteFile(testScript, []byte(scriptContent), 0755); err != nil {
+		t.Fatalf("Failed to create test script: %v", err)
+	}
+
+	registry := NewExtensionRegistry(tmpDir)
+	executor := NewExtensionExecutor(registry)
+
+	register := func(t *testing.T, name, body string) {
+		t.Helper()
+		configPath := filepath.Join(tmpDir, name+".yaml")
+		configContent := "name: " + name + "\nexecutable: " + testScript + "\ntype: executable\n" + body
+		if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+			t.Fatalf("Failed to create config: %v", err)
+		}
+		if err := registry.Register(configPath); err != nil {
+			t.Fatalf("Failed to register extension: %v", err)
+		}
+	}
+
+	t.Run("ArgsAreNotInterpretedByShell", func(t *testing.T) {
+		register(t, "args-test", `timeout: 5s
+operations:
+  echo:
+    args: ["args", "{{1}}", "prefix-{{2}}"]
+`)
+		output, err := executor.Execute("args-test", "echo", `it's "quoted"; echo injected $(id)|two words`)
+		if err != nil {
+			t.Fatalf("Failed to execute: %v", err)
+		}
+		expected := "[it's \"quoted\"; echo injected $(id)]\n[prefix-two words]\n"
+		if output != expected {
+			t.Errorf("Expected output %q, got %q", expected, output)
+		}
+	})
+
+	t.Run("CmdTemplateValuesAreQuoted", func(t *testing.T) {
+		register(t, "quoted-test", `timeout: 5s
+operations:
+  echo:
+    cmd_template: >-
+      {{executable}} args {{1}} 'in single {{2}}' "in double {{3}}" prefix-{{4}} {{5}}
+  nested:
+    cmd_template: "{{executable}} args $(echo {{1}})"
+`)
+		output, err := executor.Execute("quoted-test", "echo", "it's; echo injected $(id)|a'b|\"q\" $HOME `id` \\|two words|")
+		if err != nil {
+			t.Fatalf("Failed to execute: %v", err)
+		}
+		expected := "[it's; echo injected $(id)]\n[in single a'b]\n[in double \"q\" $HOME `id` \\]\n[prefix-two words]\n"
+		if output != expected {
+			t.Errorf("Expected output %q, got %q", expected, output)
+		}
+
+		_, err = executor.Execute("quoted-test", "nested", "x")
+		if err == nil || !strings.Contains(err.Error(), "use args") {
+			t.Errorf("Expected error for value in command substitution, got %v", err)
+		}
+	})
+
+	t.Run("ValueOnStdin", func(t *testing.T) {
+		register(t, "stdin-test", `timeout: 5s
+operations:
+  read:
+    args: ["stdin"]
+    stdin: true
+`)
+		output, err := executor.Execute("stdin-test", "read", "line; rm -rf /")
+		if err != nil {
+			t.Fatalf("Failed to execute: %v", err)
+		}
+		if output != "got: line; rm -rf /\n" {
+			t.Errorf("unexpected output %q", output)
+		}
+	})
+
+	t.Run("EnvironmentAllowlist", func(t *testing.T) {
+		t.Setenv("FABRIC_TEST_ALLOWED", "yes")
+		t.Setenv("FABRIC_TEST_SECRET", "leaked")
+		register(t, "env-test", `timeout: 5s
+env: ["FABRIC_TEST_SET=set"]
+env_allow: ["FABRIC_TEST_ALLOW*"]
+operations:
+  show:
+    args: ["env"]
+`)
+		output, err := executor.Execute("env-test", "show", "")
+		if err != nil {
+			t.Fatalf("Failed to execute: %v", err)
+		}
+		if output != "allowed=yes secret= set=set\n" {
+			t.Errorf("unexpected environment %q", output)
+		}
+	})
+
+	t.Run("StdoutTimeout", func(t *testing.T) {
+		register(t, "timeout-test", `timeout: 100ms
+operations:
+  wait:
+    cmd_template: "{{executable}} sleep"
+`)
+		_, err := executor.Execute("timeout-test", "wait", "")
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("Expected timeout error, got %v", err)
+		}
+	})
+}
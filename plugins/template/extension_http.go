@@ -0,0 +1,177 @@
+package template
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// httpRetryDelay is multiplied by the attempt number between retries
+var httpRetryDelay = 500 * time.Millisecond
+
+// executeHTTP sends the request of an operation of an http extension and
+// returns the response body, or the part chosen by select
+func (e *ExtensionExecutor) executeHTTP(ctx context.Context, ext *ExtensionDefinition, operation string, value string) (string, error) {
+	opConfig, exists := ext.Operations[operation]
+	if !exists {
+		return "", fmt.Errorf("operation %s not found for extension %s", operation, ext.Name)
+	}
+
+	vars := e.templateVariables(ext, operation, value)
+	// values in the URL are escaped so they cannot change its structure
+	urlVars := make(map[string]string, len(vars))
+	for k, v := range vars {
+		urlVars[k] = strings.ReplaceAll(url.QueryEscape(v), "+", "%20")
+	}
+	rawURL, err := ApplyTemplate(opConfig.URL, urlVars, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to format url: %w", err)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid url %q: only http and https are supported", rawURL)
+	}
+
+	headers := make(http.Header)
+	for name, tmpl := range opConfig.Headers {
+		formatted, err := ApplyTemplate(tmpl, vars, "")
+		if err != nil {
+			return "", fmt.Errorf("failed to format header %s: %w", name, err)
+		}
+		headers.Set(name, formatted)
+	}
+	var body string
+	if opConfig.Body != "" {
+		bodyVars := vars
+		if isJSONBody(headers, opConfig.Body) {
+			// values in a JSON body are escaped so they stay inside their string
+			bodyVars = make(map[string]string, len(vars))
+			for k, v := range vars {
+				bodyVars[k] = jsonEscape(v)
+			}
+		}
+		if body, err = ApplyTemplate(opConfig.Body, bodyVars, ""); err != nil {
+			return "", fmt.Errorf("failed to format body: %w", err)
+		}
+	} else if opConfig.Stdin {
+		body = value
+	}
+
+	fetchConfig, err := NewFetchConfigFromEnv()
+	if err != nil {
+		return "", err
+	}
+	// internal services are trusted per extension, the fetch plugin keeps
+	// its own setting as templates reach it with user input
+	fetchConfig.AllowPrivate = ext.AllowPrivateHosts
+	if err = fetchConfig.checkHost(u.Hostname()); err != nil {
+		return "", err
+	}
+	client := newFetchClient(fetchConfig)
+
+	var content string
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		content, retry, err = e.doHTTP(ctx, client, opConfig.GetMethod(), rawURL, headers, body)
+		if err == nil || !retry || attempt >= ext.Retries {
+			break
+		}
+		debugf("Retrying %s %s after error: %v\n", opConfig.GetMethod(), rawURL, err)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(httpRetryDelay * time.Duration(attempt+1)):
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if opConfig.Select == "" {
+		return content, nil
+	}
+	if !gjson.Valid(content) {
+		return "", fmt.Errorf("cannot select %q: response is not JSON", opConfig.Select)
+	}
+	result := gjson.Get(content, opConfig.Select)
+	if !result.Exists() {
+		return "", fmt.Errorf("path %q not found in response", opConfig.Select)
+	}
+	if result.Type == gjson.String {
+		return result.String(), nil
+	}
+	return result.Raw, nil
+}
+
+// isJSONBody reports whether the body is JSON, by the Content-Type header or
+// by the body template starting like a JSON object or array
+func isJSONBody(headers http.Header, bodyTemplate string) bool {
+	if mediaType, _, err := mime.ParseMediaType(headers.Get("Content-Type")); err == nil {
+		return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	}
+	trimmed := strings.TrimSpace(bodyTemplate)
+	return strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
+}
+
+// jsonEscape returns the value as the content of a JSON string
+func jsonEscape(value string) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded[1 : len(encoded)-1])
+}
+
+// isIdempotent reports whether a request may be sent again after the server
+// possibly received it
+func isIdempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// doHTTP sends one request with the fetch plugin's client, so the same host
+// lists and private address checks apply. retry reports whether a failure is
+// transient: 429 responses, and network errors and 5xx responses of
+// idempotent methods.
+func (e *ExtensionExecutor) doHTTP(ctx context.Context, client *http.Client, method, rawURL string, headers http.Header, body string) (content string, retry bool, err error) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header = headers.Clone()
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent)
+	}
+	debugf("Sending request: %s %s\n", method, rawURL)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", ctx.Err() == nil && isIdempotent(method), fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxContentSize+1))
+	if err != nil {
+		return "", isIdempotent(method), fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(data) > MaxContentSize {
+		return "", false, fmt.Errorf("response exceeds maximum size of %d bytes", MaxContentSize)
+	}
+	if resp.StatusCode >= 400 {
+		retry = resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode >= 500 && isIdempotent(method))
+		return "", retry, fmt.Errorf("request failed with status %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return string(data), false, nil
+}
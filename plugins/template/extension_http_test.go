@@ -0,0 +1,187 @@
+package template
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPExtension(t *testing.T) {
+	var flaky, flakyPost atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{
+				"method": r.Method,
+				"query":  r.URL.Query().Get("q"),
+				"token":  r.Header.Get("Authorization"),
+				"body":   string(body),
+				"items":  []int{1, 2},
+			})
+		case "/flaky":
+			if flaky.Add(1) < 3 {
+				http.Error(w, "try again", http.StatusServiceUnavailable)
+				return
+			}
+			io.WriteString(w, "recovered")
+		case "/flakypost":
+			flakyPost.Add(1)
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+			io.WriteString(w, "late")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	oldDelay := httpRetryDelay
+	httpRetryDelay = time.Millisecond
+	defer func() { httpRetryDelay = oldDelay }()
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "service.yaml")
+	config := `name: service
+type: http
+timeout: 200ms
+retries: 2
+allow_private_hosts: true
+operations:
+  search:
+    url: "` + server.URL + `/echo?q={{value}}"
+    select: query
+  post:
+    method: post
+    url: "` + server.URL + `/echo"
+    headers:
+      Authorization: "Bearer {{1}}"
+    body: '{"text": "{{2}}"}'
+    select: body
+  items:
+    url: "` + server.URL + `/echo"
+    select: items
+  flaky:
+    url: "` + server.URL + `/flaky"
+  flakypost:
+    method: post
+    url: "` + server.URL + `/flakypost"
+  slow:
+    url: "` + server.URL + `/slow"
+  missing:
+    url: "` + server.URL + `/missing"
+  badselect:
+    url: "` + server.URL + `/echo"
+    select: nothing.here
+`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewExtensionRegistry(tmpDir)
+	if err := registry.Register(configPath); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	executor := NewExtensionExecutor(registry)
+
+	tests := []struct {
+		name      string
+		operation string
+		value     string
+		want      string
+		wantErr   string
+	}{
+		{name: "escaped url value", operation: "search", value: "a b&c=d", want: "a b&c=d"},
+		{name: "headers and body", operation: "post", value: "secret|hello", want: `{"text": "hello"}`},
+		{name: "escaped json body value", operation: "post", value: "secret|say \"hi\", \\o/\nbye", want: `{"text": "say \"hi\", \\o/\nbye"}`},
+		{name: "non-string selection", operation: "items", want: "[1,2]"},
+		{name: "retries transient errors", operation: "flaky", want: "recovered"},
+		{name: "post is not retried", operation: "flakypost", wantErr: "503"},
+		{name: "timeout", operation: "slow", wantErr: "timed out"},
+		{name: "client error", operation: "missing", wantErr: "404"},
+		{name: "missing path", operation: "badselect", wantErr: "not found in response"},
+		{name: "unknown operation", operation: "nope", wantErr: "operation nope not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := executor.Execute("service", tt.operation, tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Execute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if n := flakyPost.Load(); n != 1 {
+		t.Errorf("POST sent %d times, want 1", n)
+	}
+
+	// private addresses need allow_private_hosts, the fetch plugin's
+	// setting does not apply
+	t.Setenv(FetchAllowPrivateEnv, "true")
+	privateConfigPath := filepath.Join(tmpDir, "private.yaml")
+	privateConfig := "name: private\ntype: http\noperations:\n  search:\n    url: \"" + server.URL + "/echo\"\n"
+	if err := os.WriteFile(privateConfigPath, []byte(privateConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(privateConfigPath); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if _, err := executor.Execute("private", "search", "x"); err == nil || !strings.Contains(err.Error(), "private address") {
+		t.Errorf("Execute() error = %v, want private address error", err)
+	}
+}
+
+func TestHTTPExtensionValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:    "missing url",
+			config:  "name: bad\ntype: http\noperations:\n  get:\n    method: GET\n",
+			wantErr: "url is required",
+		},
+		{
+			name:    "command in http extension",
+			config:  "name: bad\ntype: http\noperations:\n  get:\n    url: http://localhost/\n    cmd_template: echo\n",
+			wantErr: "cannot have cmd_template",
+		},
+		{
+			name:    "url in executable extension",
+			config:  "name: bad\ntype: executable\nexecutable: /bin/echo\noperations:\n  get:\n    url: http://localhost/\n",
+			wantErr: "extension type is not http",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			configPath := filepath.Join(tmpDir, "bad.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			err := NewExtensionRegistry(tmpDir).Register(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Register() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
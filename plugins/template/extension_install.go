@@ -0,0 +1,238 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/otiai10/copy"
+	"gopkg.in/yaml.v3"
+)
+
+// ExtensionManifestName is the manifest looked up in the root of extension
+// directories and repositories
+const ExtensionManifestName = "extension.yaml"
+
+var extensionNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// InstallExtension handles the addextension flag action. source is a config
+// file, a directory or a git URL containing an extension manifest. Directories
+// and repositories are copied to extensions/<name> in the config directory.
+func (em *ExtensionManager) InstallExtension(source string) error {
+	if info, err := os.Stat(source); err == nil && !info.IsDir() {
+		return em.RegisterExtension(source)
+	}
+	return em.install(source, "")
+}
+
+// UpdateExtension handles the update-extension flag action. It installs the
+// extension again from the directory or repository it was installed from.
+func (em *ExtensionManager) UpdateExtension(name string) error {
+	entry, exists := em.registry.registry.Extensions[name]
+	if !exists {
+		return fmt.Errorf("extension %s not found", name)
+	}
+	if entry.Source == "" {
+		return fmt.Errorf("extension %s was registered from a config file, register it again with --addextension", name)
+	}
+	if err := em.registry.Verify(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: installed extension %s was modified, local changes will be replaced: %v\n", name, err)
+	}
+	return em.install(entry.Source, name)
+}
+
+// install copies the extension found at source into the extensions directory
+// and registers it. name is the installed extension to replace, if any.
+func (em *ExtensionManager) install(source string, name string) (err error) {
+	srcDir, commit, cleanup, err := fetchExtensionSource(source)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if commit == "" {
+		source = srcDir // record local directories with their absolute path
+	}
+
+	manifest, err := findExtensionManifest(srcDir)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(srcDir, manifest))
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var ext ExtensionDefinition
+	if err = yaml.Unmarshal(data, &ext); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if !extensionNamePattern.MatchString(ext.Name) {
+		return fmt.Errorf("invalid extension name '%s': use letters, digits, '.', '_' and '-'", ext.Name)
+	}
+
+	if name == "" {
+		if _, exists := em.registry.registry.Extensions[ext.Name]; exists {
+			return fmt.Errorf("extension %s is already registered, use --update-extension %s", ext.Name, ext.Name)
+		}
+	} else if ext.Name != name {
+		return fmt.Errorf("%s now provides extension %s instead of %s", source, ext.Name, name)
+	}
+
+	// copy next to the target first so a failed copy keeps the old version
+	installDir := filepath.Join(em.configDir, "extensions", ext.Name)
+	tmpDir := installDir + ".new"
+	if err = os.RemoveAll(tmpDir); err != nil {
+		return fmt.Errorf("failed to prepare install directory: %w", err)
+	}
+	if err = copy.Copy(srcDir, tmpDir, copy.Options{
+		Skip: func(_ os.FileInfo, src, _ string) (bool, error) {
+			return filepath.Base(src) == ".git", nil
+		},
+	}); err != nil {
+		os.RemoveAll(tmpDir)
+		return fmt.Errorf("failed to copy extension: %w", err)
+	}
+	// the previous version is kept until the new one is registered
+	backupDir := installDir + ".old"
+	if err = os.RemoveAll(backupDir); err != nil {
+		os.RemoveAll(tmpDir)
+		return fmt.Errorf("failed to prepare backup directory: %w", err)
+	}
+	hadPrevious := false
+	if _, statErr := os.Stat(installDir); statErr == nil {
+		if err = os.Rename(installDir, backupDir); err != nil {
+			os.RemoveAll(tmpDir)
+			return fmt.Errorf("failed to back up previous version: %w", err)
+		}
+		hadPrevious = true
+	}
+	previous := em.registry.registry.Extensions[ext.Name]
+	restore := func() {
+		os.RemoveAll(installDir)
+		if hadPrevious {
+			if renameErr := os.Rename(backupDir, installDir); renameErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to restore previous version from %s: %v\n", backupDir, renameErr)
+			}
+		}
+		if em.registry.registry.Extensions[ext.Name] != previous {
+			if previous != nil {
+				em.registry.registry.Extensions[ext.Name] = previous
+			} else {
+				delete(em.registry.registry.Extensions, ext.Name)
+			}
+			em.registry.saveRegistry()
+		}
+	}
+	if err = os.Rename(tmpDir, installDir); err != nil {
+		os.RemoveAll(tmpDir)
+		restore()
+		return fmt.Errorf("failed to install extension: %w", err)
+	}
+
+	if err = em.registry.Register(filepath.Join(installDir, manifest)); err != nil {
+		restore()
+		return fmt.Errorf("failed to register extension: %w", err)
+	}
+
+	entry := em.registry.registry.Extensions[ext.Name]
+	entry.Source = source
+	entry.Version = ext.Version
+	entry.Commit = commit
+	entry.InstallDir = installDir
+	if entry.Files, err = ComputeFileHashes(installDir); err == nil {
+		err = em.registry.saveRegistry()
+	}
+	if err != nil {
+		restore()
+		return fmt.Errorf("failed to record extension: %w", err)
+	}
+	os.RemoveAll(backupDir)
+
+	// the hashes were calculated from the installed copy, check it is intact
+	if err = em.registry.Verify(ext.Name); err != nil {
+		return fmt.Errorf("installed extension failed verification: %w", err)
+	}
+
+	action := "Installed"
+	if name != "" {
+		action = "Updated"
+	}
+	fmt.Printf("%s extension %s", action, ext.Name)
+	if ext.Version != "" {
+		fmt.Printf(" %s", ext.Version)
+	}
+	fmt.Printf(" from %s\n", source)
+	if commit != "" {
+		fmt.Printf("  Commit: %s\n", commit)
+	}
+	fmt.Printf("  Directory: %s\n", installDir)
+	return nil
+}
+
+// fetchExtensionSource returns a local directory with the contents of source,
+// cloning git repositories into a temporary directory removed by cleanup
+func fetchExtensionSource(source string) (dir string, commit string, cleanup func(), err error) {
+	cleanup = func() {}
+	if !isGitURL(source) {
+		if info, statErr := os.Stat(source); statErr == nil && info.IsDir() {
+			dir, err = filepath.Abs(source)
+			return
+		}
+		if !strings.HasSuffix(source, ".git") && !strings.HasPrefix(source, "git@") {
+			err = fmt.Errorf("%s is not a config file, directory or git URL", source)
+			return
+		}
+	}
+
+	if dir, err = os.MkdirTemp("", "fabric-extension-*"); err != nil {
+		return
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	options := &git.CloneOptions{URL: source}
+	if !strings.HasPrefix(source, "file://") {
+		options.Depth = 1
+	}
+	repo, err := git.PlainClone(dir, false, options)
+	if err != nil {
+		cleanup()
+		err = fmt.Errorf("failed to clone %s: %w", source, err)
+		return
+	}
+	head, err := repo.Head()
+	if err != nil {
+		cleanup()
+		err = fmt.Errorf("failed to resolve HEAD of %s: %w", source, err)
+		return
+	}
+	commit = head.Hash().String()
+	return
+}
+
+func isGitURL(source string) bool {
+	for _, prefix := range []string{"http://", "https://", "git://", "ssh://", "file://", "git@"} {
+		if strings.HasPrefix(source, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// findExtensionManifest returns the manifest of an extension directory:
+// extension.yaml or else the only YAML file in the root
+func findExtensionManifest(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, ExtensionManifestName)); err == nil {
+		return ExtensionManifestName, nil
+	}
+	var candidates []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		candidates = append(candidates, matches...)
+	}
+	if len(candidates) != 1 {
+		return "", fmt.Errorf("no %s found in %s", ExtensionManifestName, dir)
+	}
+	return filepath.Base(candidates[0]), nil
+}
@@ -0,0 +1,234 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// writeTestExtension writes an extension with a relative executable to dir
+func writeTestExtension(t *testing.T, dir, version, greeting string) {
+	t.Helper()
+	manifest := `name: greeter
+executable: greet.sh
+type: executable
+timeout: 5s
+description: "Greets"
+version: "` + version + `"
+operations:
+  hello:
+    cmd_template: "{{executable}} {{value}}"
+`
+	script := "#!/bin/sh\necho \"" + greeting + ", $1\"\n"
+	if err := os.WriteFile(filepath.Join(dir, ExtensionManifestName), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "greet.sh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstallExtensionFromDirectory(t *testing.T) {
+	srcDir := t.TempDir()
+	writeTestExtension(t, srcDir, "1.0.0", "Hello")
+
+	manager := NewExtensionManager(t.TempDir())
+	if err := manager.InstallExtension(srcDir); err != nil {
+		t.Fatalf("InstallExtension() error = %v", err)
+	}
+	if err := manager.InstallExtension(srcDir); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("second InstallExtension() error = %v, want already registered", err)
+	}
+
+	entry := manager.registry.registry.Extensions["greeter"]
+	if entry.Source != srcDir || entry.Version != "1.0.0" || entry.Commit != "" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if !strings.HasPrefix(entry.ConfigPath, entry.InstallDir) {
+		t.Errorf("config %s not in install dir %s", entry.ConfigPath, entry.InstallDir)
+	}
+
+	got, err := manager.ProcessExtension("greeter", "hello", "World")
+	if err != nil || got != "Hello, World\n" {
+		t.Errorf("ProcessExtension() = %q, %v", got, err)
+	}
+
+	// the installed copy is independent of the source, and updates replace
+	// local modifications of it
+	writeTestExtension(t, srcDir, "1.1.0", "Hi")
+	if err := os.WriteFile(filepath.Join(entry.InstallDir, "greet.sh"), []byte("#!/bin/sh\necho changed\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.ProcessExtension("greeter", "hello", "World"); err == nil {
+		t.Error("modified extension should fail verification")
+	}
+	if err := manager.UpdateExtension("greeter"); err != nil {
+		t.Fatalf("UpdateExtension() error = %v", err)
+	}
+	got, err = manager.ProcessExtension("greeter", "hello", "World")
+	if err != nil || got != "Hi, World\n" {
+		t.Errorf("ProcessExtension() after update = %q, %v", got, err)
+	}
+}
+
+func TestInstallExtensionFromGit(t *testing.T) {
+	repoDir := t.TempDir()
+	repo, err := git.PlainInit(repoDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(version, greeting string) string {
+		writeTestExtension(t, repoDir, version, greeting)
+		if _, err := wt.Add("."); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := wt.Commit("release "+version, &git.CommitOptions{
+			Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash.String()
+	}
+
+	first := commit("1.0.0", "Hello")
+	source := "file://" + filepath.ToSlash(repoDir)
+	manager := NewExtensionManager(t.TempDir())
+	if err := manager.InstallExtension(source); err != nil {
+		t.Fatalf("InstallExtension() error = %v", err)
+	}
+	entry := manager.registry.registry.Extensions["greeter"]
+	if entry.Source != source || entry.Commit != first {
+		t.Errorf("entry = %+v, want commit %s", entry, first)
+	}
+	if _, err := os.Stat(filepath.Join(entry.InstallDir, ".git")); !os.IsNotExist(err) {
+		t.Error(".git should not be installed")
+	}
+
+	second := commit("2.0.0", "Howdy")
+	if err := manager.UpdateExtension("greeter"); err != nil {
+		t.Fatalf("UpdateExtension() error = %v", err)
+	}
+
+	// the registry is persisted with the new version
+	reloaded := NewExtensionManager(manager.configDir)
+	entry = reloaded.registry.registry.Extensions["greeter"]
+	if entry.Commit != second || entry.Version != "2.0.0" {
+		t.Errorf("entry after update = %+v, want commit %s", entry, second)
+	}
+	got, err := reloaded.ProcessExtension("greeter", "hello", "World")
+	if err != nil || got != "Howdy, World\n" {
+		t.Errorf("ProcessExtension() = %q, %v", got, err)
+	}
+}
+
+func TestInstallExtensionErrors(t *testing.T) {
+	manager := NewExtensionManager(t.TempDir())
+
+	emptyDir := t.TempDir()
+	if err := manager.InstallExtension(emptyDir); err == nil || !strings.Contains(err.Error(), ExtensionManifestName) {
+		t.Errorf("empty directory error = %v", err)
+	}
+	if err := manager.InstallExtension(filepath.Join(emptyDir, "missing")); err == nil {
+		t.Error("missing source should fail")
+	}
+	if err := manager.UpdateExtension("missing"); err == nil {
+		t.Error("updating an unknown extension should fail")
+	}
+
+	badName := t.TempDir()
+	writeTestExtension(t, badName, "1.0.0", "Hello")
+	data, _ := os.ReadFile(filepath.Join(badName, ExtensionManifestName))
+	data = []byte(strings.Replace(string(data), "name: greeter", "name: ../escape", 1))
+	if err := os.WriteFile(filepath.Join(badName, ExtensionManifestName), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.InstallExtension(badName); err == nil || !strings.Contains(err.Error(), "invalid extension name") {
+		t.Errorf("bad name error = %v", err)
+	}
+}
+
+func TestUpdateExtensionKeepsPreviousVersionOnFailure(t *testing.T) {
+	srcDir := t.TempDir()
+	writeTestExtension(t, srcDir, "1.0.0", "Hello")
+	manager := NewExtensionManager(t.TempDir())
+	if err := manager.InstallExtension(srcDir); err != nil {
+		t.Fatalf("InstallExtension() error = %v", err)
+	}
+	installDir := manager.registry.registry.Extensions["greeter"].InstallDir
+
+	// the new version cannot be registered without its executable
+	writeTestExtension(t, srcDir, "2.0.0", "Hi")
+	if err := os.Remove(filepath.Join(srcDir, "greet.sh")); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.UpdateExtension("greeter"); err == nil {
+		t.Fatal("UpdateExtension() should fail without the executable")
+	}
+
+	for _, dir := range []string{installDir + ".new", installDir + ".old"} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", dir)
+		}
+	}
+	reloaded := NewExtensionManager(manager.configDir)
+	if entry := reloaded.registry.registry.Extensions["greeter"]; entry.Version != "1.0.0" {
+		t.Errorf("entry after failed update = %+v", entry)
+	}
+	got, err := reloaded.ProcessExtension("greeter", "hello", "World")
+	if err != nil || got != "Hello, World\n" {
+		t.Errorf("ProcessExtension() after failed update = %q, %v", got, err)
+	}
+}
+
+func TestInstalledHelperFilesAreVerified(t *testing.T) {
+	srcDir := t.TempDir()
+	writeTestExtension(t, srcDir, "1.0.0", "Hello")
+	if err := os.MkdirAll(filepath.Join(srcDir, "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "lib", "helper.sh"), []byte("echo helper\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manager := NewExtensionManager(t.TempDir())
+	if err := manager.InstallExtension(srcDir); err != nil {
+		t.Fatalf("InstallExtension() error = %v", err)
+	}
+	entry := manager.registry.registry.Extensions["greeter"]
+	if _, ok := entry.Files["lib/helper.sh"]; !ok {
+		t.Fatalf("Files = %v, want lib/helper.sh recorded", entry.Files)
+	}
+
+	// files created at runtime are not part of the installed extension
+	if err := os.MkdirAll(filepath.Join(entry.InstallDir, "__pycache__"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(entry.InstallDir, "__pycache__", "cache.pyc"), []byte("cache"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.ProcessExtension("greeter", "hello", "World"); err != nil {
+		t.Errorf("ProcessExtension() with generated files error = %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(entry.InstallDir, "lib", "helper.sh"), []byte("echo changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.ProcessExtension("greeter", "hello", "World"); err == nil || !strings.Contains(err.Error(), "lib/helper.sh") {
+		t.Errorf("ProcessExtension() with modified helper error = %v", err)
+	}
+	if err := os.Remove(filepath.Join(entry.InstallDir, "lib", "helper.sh")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.ProcessExtension("greeter", "hello", "World"); err == nil {
+		t.Error("ProcessExtension() with removed helper should fail")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -54,7 +55,7 @@ func (em *ExtensionManager) ListExtensions() error {
 		fmt.Printf("  Operations:\n")
 		for opName, opConfig := range ext.Operations {
 			fmt.Printf("    %s:\n", opName)
-			fmt.Printf("      Command Template: %s\n", opConfig.CmdTemplate)
+			fmt.Printf("      Command Template: %s\n", opConfig.Command())
 		}
 
 		if fileConfig := ext.GetFileConfig(); fileConfig != nil {
@@ -107,7 +108,7 @@ func (em *ExtensionManager) RegisterExtension(configPath string) error {
 	fmt.Printf("  Operations:\n")
 	for opName, opConfig := range ext.Operations {
 		fmt.Printf("    %s:\n", opName)
-		fmt.Printf("      Command Template: %s\n", opConfig.CmdTemplate)
+		fmt.Printf("      Command Template: %s\n", opConfig.Command())
 	}
 
 	if fileConfig := ext.GetFileConfig(); fileConfig != nil {
@@ -133,3 +134,66 @@ func (em *ExtensionManager) RemoveExtension(name string) error {
 func (em *ExtensionManager) ProcessExtension(name, operation, value string) (string, error) {
 	return em.executor.Execute(name, operation, value)
 }
+
+// Plugin returns a registered extension as a template namespace
+func (em *ExtensionManager) Plugin(name string) (Plugin, error) {
+	ext, err := em.registry.GetExtension(name)
+	if err != nil {
+		return nil, err
+	}
+	return &extensionPlugin{ext: ext, executor: em.executor}, nil
+}
+
+// Plugins returns all enabled extensions as template namespaces sorted by
+// name. Extensions failing verification are left out.
+func (em *ExtensionManager) Plugins() (ret []Plugin, err error) {
+	if em.registry == nil || em.registry.registry.Extensions == nil {
+		return nil, fmt.Errorf("extension registry not initialized")
+	}
+
+	var names []string
+	for name := range em.registry.registry.Extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		plugin, pluginErr := em.Plugin(name)
+		if pluginErr != nil {
+			debugf("Skipping extension %s: %v\n", name, pluginErr)
+			continue
+		}
+		ret = append(ret, plugin)
+	}
+	return
+}
+
+// extensionPlugin adapts an extension to the Plugin interface
+type extensionPlugin struct {
+	ext      *ExtensionDefinition
+	executor *ExtensionExecutor
+}
+
+func (p *extensionPlugin) Name() string {
+	return p.ext.Name
+}
+
+func (p *extensionPlugin) Description() string {
+	return p.ext.Description
+}
+
+func (p *extensionPlugin) Operations() (ret []Operation) {
+	for name, op := range p.ext.Operations {
+		ret = append(ret, Operation{
+			Name:        name,
+			Usage:       fmt.Sprintf("{{ext:%s:%s:value}}", p.ext.Name, name),
+			Description: op.Command(),
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return
+}
+
+func (p *extensionPlugin) Apply(operation string, value string) (string, error) {
+	return p.executor.Execute(p.ext.Name, operation, value)
+}
@@ -77,6 +77,25 @@ operations:
 		}
 	})
 
+	t.Run("ExtensionNamespace", func(t *testing.T) {
+		registry := NewPluginRegistry(manager)
+
+		plugin, err := registry.Extension("test-extension")
+		if err != nil {
+			t.Fatalf("Failed to get extension namespace: %v", err)
+		}
+		if ops := plugin.Operations(); len(ops) != 1 || ops[0].Usage != "{{ext:test-extension:echo:value}}" {
+			t.Errorf("Unexpected operations %+v", ops)
+		}
+		output, err := plugin.Apply("echo", "Registry")
+		if err != nil {
+			t.Fatalf("Failed to apply extension namespace: %v", err)
+		}
+		if output != "Hello, Registry!\n" {
+			t.Errorf("Expected output %q, got %q", "Hello, Registry!\n", output)
+		}
+	})
+
 	t.Run("RemoveExtension", func(t *testing.T) {
 		err := manager.RemoveExtension("test-extension")
 		if err != nil {
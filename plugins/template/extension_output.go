@@ -0,0 +1,133 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Output formats of extensions, set as config.output.format
+const (
+	ExtensionFormatText = "text"
+	ExtensionFormatJSON = "json"
+)
+
+// Embed modes for JSON responses, set as config.output.embed
+const (
+	ExtensionEmbedAuto   = "auto"   // fence everything that is not plain text or markdown
+	ExtensionEmbedRaw    = "raw"    // insert results as they are
+	ExtensionEmbedFenced = "fenced" // always use a fenced code block
+)
+
+// ExtensionResultField addresses the main result in {{ext:name:op:arg#field}}
+const ExtensionResultField = "result"
+
+// ExtensionResponse is the JSON document written by extensions with
+// config.output.format set to json:
+//
+//	{"result": "...", "content_type": "text/markdown", "warnings": ["..."],
+//	 "error": "...", "outputs": {"summary": "...", "data": {"a": 1}}}
+type ExtensionResponse struct {
+	Result      string                     `json:"result"`
+	ContentType string                     `json:"content_type,omitempty"`
+	Warnings    []string                   `json:"warnings,omitempty"`
+	Error       string                     `json:"error,omitempty"`
+	Outputs     map[string]json.RawMessage `json:"outputs,omitempty"`
+}
+
+// Field returns the result or a named output with its content type. Outputs
+// that are not JSON strings are returned as JSON.
+func (r *ExtensionResponse) Field(name string) (value string, contentType string, err error) {
+	if name == "" || name == ExtensionResultField {
+		return r.Result, r.ContentType, nil
+	}
+	raw, ok := r.Outputs[name]
+	if !ok {
+		var names []string
+		for n := range r.Outputs {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", "", fmt.Errorf("no output %q (available: %s)", name,
+			strings.Join(append([]string{ExtensionResultField}, names...), ", "))
+	}
+	if err = json.Unmarshal(raw, &value); err == nil {
+		return value, "text/plain", nil
+	}
+	return string(raw), "application/json", nil
+}
+
+var outputFieldPattern = regexp.MustCompile(`(\\?)#([A-Za-z_][A-Za-z0-9_-]*)$`)
+
+// splitOutputField removes a trailing #field selector from a value. \# is a
+// literal #, so page\#intro is the value page#intro without a selector.
+func splitOutputField(value string) (rest string, field string) {
+	rest = value
+	if m := outputFieldPattern.FindStringSubmatchIndex(value); m != nil && m[3] == m[2] {
+		rest, field = value[:m[0]], value[m[4]:m[5]]
+	}
+	return strings.ReplaceAll(rest, `\#`, "#"), field
+}
+
+// decodeResponse parses the JSON response of an extension. runErr is the
+// error of the process; an error reported in the response takes precedence
+// because it is more meaningful than an exit status.
+func (e *ExtensionExecutor) decodeResponse(ext *ExtensionDefinition, output string, runErr error, field string) (string, error) {
+	var resp ExtensionResponse
+	if err := json.Unmarshal([]byte(output), &resp); err != nil {
+		if runErr != nil {
+			return "", runErr
+		}
+		return "", fmt.Errorf("invalid JSON response: %w", err)
+	}
+
+	for _, warning := range resp.Warnings {
+		fmt.Fprintf(os.Stderr, "extension %s: warning: %s\n", ext.Name, warning)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("%s", resp.Error)
+	}
+	if runErr != nil {
+		return "", runErr
+	}
+
+	value, contentType, err := resp.Field(field)
+	if err != nil {
+		return "", err
+	}
+	return embedResult(value, contentType, ext.GetEmbedMode()), nil
+}
+
+// embedResult formats a result for insertion into a template
+func embedResult(value, contentType, mode string) string {
+	language := ""
+	if contentType != "" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			contentType = mediaType
+		}
+		_, subtype, _ := strings.Cut(contentType, "/")
+		subtype = strings.TrimPrefix(subtype, "x-")
+		if i := strings.LastIndex(subtype, "+"); i >= 0 {
+			subtype = subtype[i+1:]
+		}
+		language = subtype
+	}
+
+	switch mode {
+	case ExtensionEmbedRaw:
+		return value
+	case ExtensionEmbedFenced:
+	default:
+		if contentType == "" || contentType == "text/plain" || contentType == "text/markdown" {
+			return value
+		}
+	}
+	if language == "plain" || language == "markdown" {
+		language = ""
+	}
+	return "```" + language + "\n" + strings.TrimSuffix(value, "\n") + "\n```"
+}
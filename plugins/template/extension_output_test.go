@@ -0,0 +1,157 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitOutputField(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantRest  string
+		wantField string
+	}{
+		{value: "arg#summary", wantRest: "arg", wantField: "summary"},
+		{value: "a|b#data_2", wantRest: "a|b", wantField: "data_2"},
+		{value: "https://site/page#intro", wantRest: "https://site/page", wantField: "intro"},
+		{value: `https://site/page\#intro`, wantRest: "https://site/page#intro"},
+		{value: `https://site/page\#intro#summary`, wantRest: "https://site/page#intro", wantField: "summary"},
+		{value: `a\#b c\#d`, wantRest: "a#b c#d"},
+		{value: "C#", wantRest: "C#"},
+		{value: "issue#12", wantRest: "issue#12"},
+		{value: "#result", wantRest: "", wantField: "result"},
+		{value: "plain", wantRest: "plain"},
+	}
+	for _, tt := range tests {
+		rest, field := splitOutputField(tt.value)
+		if rest != tt.wantRest || field != tt.wantField {
+			t.Errorf("splitOutputField(%q) = %q, %q, want %q, %q", tt.value, rest, field, tt.wantRest, tt.wantField)
+		}
+	}
+}
+
+func TestEmbedResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		contentType string
+		mode        string
+		want        string
+	}{
+		{name: "no content type", value: "text", mode: ExtensionEmbedAuto, want: "text"},
+		{name: "markdown", value: "# Title", contentType: "text/markdown; charset=utf-8", mode: ExtensionEmbedAuto, want: "# Title"},
+		{name: "json fenced", value: "{\"a\":1}\n", contentType: "application/json", mode: ExtensionEmbedAuto, want: "```json\n{\"a\":1}\n```"},
+		{name: "vendor suffix", value: "a: 1", contentType: "application/vnd.api+yaml", mode: ExtensionEmbedAuto, want: "```yaml\na: 1\n```"},
+		{name: "x- prefix", value: "print(1)", contentType: "text/x-python", mode: ExtensionEmbedAuto, want: "```python\nprint(1)\n```"},
+		{name: "raw mode", value: "{}", contentType: "application/json", mode: ExtensionEmbedRaw, want: "{}"},
+		{name: "fenced plain text", value: "log line", contentType: "text/plain", mode: ExtensionEmbedFenced, want: "```\nlog line\n```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := embedResult(tt.value, tt.contentType, tt.mode); got != tt.want {
+				t.Errorf("embedResult() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtensionJSONOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testScript := filepath.Join(tmpDir, "json-script.sh")
+	scriptContent := `#!/bin/sh
+case "$1" in
+    "ok")
+        echo '{"result": "hello '"$2"'", "warnings": ["slow"], "outputs": {"summary": "short", "data": {"n": 1}}}'
+        ;;
+    "code")
+        echo '{"result": "{\"n\": 1}", "content_type": "application/json"}'
+        ;;
+    "fail")
+        echo '{"error": "quota exceeded"}'
+        exit 3
+        ;;
+    "garbage")
+        echo 'not json'
+        ;;
+esac`
+	if err := os.WriteFile(testScript, []byte(scriptContent), 0755); err != nil {
+		t.Fatalf("Failed to create test script: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "json-test.yaml")
+	configContent := `name: json-test
+executable: ` + testScript + `
+type: executable
+timeout: 5s
+operations:
+  ok:
+    args: ["ok", "{{value}}"]
+  code:
+    args: ["code"]
+  fail:
+    args: ["fail"]
+  garbage:
+    args: ["garbage"]
+config:
+  output:
+    method: stdout
+    format: json`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	registry := NewExtensionRegistry(tmpDir)
+	if err := registry.Register(configPath); err != nil {
+		t.Fatalf("Failed to register extension: %v", err)
+	}
+	executor := NewExtensionExecutor(registry)
+
+	tests := []struct {
+		name        string
+		operation   string
+		value       string
+		want        string
+		errContains string
+	}{
+		{name: "result", operation: "ok", value: "world", want: "hello world"},
+		{name: "named output", operation: "ok", value: "world#summary", want: "short"},
+		{name: "structured output", operation: "ok", value: "world#data", want: "```json\n{\"n\": 1}\n```"},
+		{name: "field without value", operation: "ok#summary", want: "short"},
+		{name: "escaped hash is part of the value", operation: "ok", value: `page\#intro`, want: "hello page#intro"},
+		{name: "unknown output", operation: "ok", value: "world#nope", errContains: "available: result, data, summary"},
+		{name: "content type", operation: "code", want: "```json\n{\"n\": 1}\n```"},
+		{name: "reported error wins over exit status", operation: "fail", errContains: "quota exceeded"},
+		{name: "invalid response", operation: "garbage", errContains: "invalid JSON response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := executor.Execute("json-test", tt.operation, tt.value)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Execute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		badPath := filepath.Join(tmpDir, "bad.yaml")
+		bad := strings.Replace(configContent, "format: json", "format: xml", 1)
+		if err := os.WriteFile(badPath, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := registry.Register(badPath); err == nil {
+			t.Error("expected error for unsupported output format")
+		}
+	})
+}
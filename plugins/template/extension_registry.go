@@ -5,13 +5,20 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
-	// Add this import
+)
+
+// Extension types
+const (
+	ExtensionTypeExecutable = "executable"
+	ExtensionTypeHTTP       = "http" // operations are requests, no process is spawned
 )
 
 // ExtensionDefinition represents a single extension configuration
@@ -24,6 +31,14 @@ type ExtensionDefinition struct {
 	Description string   `yaml:"description"`
 	Version     string   `yaml:"version"`
 	Env         []string `yaml:"env"`
+	// EnvAllow lists the variables of fabric's environment passed to the
+	// executable in addition to DefaultExtensionEnv; NAME_* matches a prefix
+	EnvAllow []string `yaml:"env_allow"`
+	// Retries of failed requests of http extensions
+	Retries int `yaml:"retries"`
+	// AllowPrivateHosts lets an http extension reach loopback and private
+	// network addresses, independently of FETCH_ALLOW_PRIVATE_IPS
+	AllowPrivateHosts bool `yaml:"allow_private_hosts"`
 
 	// Operation-specific commands
 	Operations map[string]OperationConfig `yaml:"operations"`
@@ -32,8 +47,42 @@ type ExtensionDefinition struct {
 	Config map[string]interface{} `yaml:"config"`
 }
 
+// OperationConfig defines how an operation runs. CmdTemplate is a shell
+// command line; Args runs the executable directly, templating every
+// argument on its own so values are never interpreted by a shell.
+// Operations of http extensions send a request built from Method, URL,
+// Headers and Body instead.
 type OperationConfig struct {
-	CmdTemplate string `yaml:"cmd_template"`
+	CmdTemplate string   `yaml:"cmd_template"`
+	Args        []string `yaml:"args"`
+	// Stdin passes the value on standard input instead of the command line
+	Stdin bool `yaml:"stdin"`
+
+	Method  string            `yaml:"method"` // GET unless set
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+	// Select is a JSON path (gjson syntax) applied to the response body
+	Select string `yaml:"select"`
+}
+
+// Command describes the command of an operation for listings
+func (o OperationConfig) Command() string {
+	if o.URL != "" {
+		return o.GetMethod() + " " + o.URL
+	}
+	if len(o.Args) > 0 {
+		return "{{executable}} " + strings.Join(o.Args, " ")
+	}
+	return o.CmdTemplate
+}
+
+// GetMethod returns the HTTP method of an operation
+func (o OperationConfig) GetMethod() string {
+	if o.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(o.Method)
 }
 
 // RegistryEntry represents a registered extension
@@ -41,6 +90,35 @@ type RegistryEntry struct {
 	ConfigPath     string `yaml:"config_path"`
 	ConfigHash     string `yaml:"config_hash"`
 	ExecutableHash string `yaml:"executable_hash"`
+
+	// Set for extensions installed from a directory or git repository
+	Source     string `yaml:"source,omitempty"`
+	Version    string `yaml:"version,omitempty"`
+	Commit     string `yaml:"commit,omitempty"`
+	InstallDir string `yaml:"install_dir,omitempty"`
+	// hashes of the files installed with the extension, helper scripts
+	// included; files the extension creates later are not checked
+	Files map[string]string `yaml:"files,omitempty"`
+}
+
+// verifyFiles checks the files installed with the extension, if any
+func (e *RegistryEntry) verifyFiles(name string) error {
+	files := make([]string, 0, len(e.Files))
+	for rel := range e.Files {
+		files = append(files, rel)
+	}
+	sort.Strings(files)
+	for _, rel := range files {
+		hash := e.Files[rel]
+		current, err := computeEntryHash(filepath.Join(e.InstallDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return fmt.Errorf("failed to verify %s of %s: %w", rel, name, err)
+		}
+		if current != hash {
+			return fmt.Errorf("file hash mismatch for %s of %s", rel, name)
+		}
+	}
+	return nil
 }
 
 type ExtensionRegistry struct {
@@ -60,6 +138,26 @@ func (e *ExtensionDefinition) GetOutputMethod() string {
 	return "stdout" // default to stdout if not specified
 }
 
+// GetOutputFormat returns config.output.format, text unless set to json
+func (e *ExtensionDefinition) GetOutputFormat() string {
+	if output, ok := e.Config["output"].(map[string]interface{}); ok {
+		if format, ok := output["format"].(string); ok && format != "" {
+			return format
+		}
+	}
+	return ExtensionFormatText
+}
+
+// GetEmbedMode returns how JSON results are inserted into templates
+func (e *ExtensionDefinition) GetEmbedMode() string {
+	if output, ok := e.Config["output"].(map[string]interface{}); ok {
+		if embed, ok := output["embed"].(string); ok && embed != "" {
+			return embed
+		}
+	}
+	return ExtensionEmbedAuto
+}
+
 func (e *ExtensionDefinition) GetFileConfig() map[string]interface{} {
 	if output, ok := e.Config["output"].(map[string]interface{}); ok {
 		if fileConfig, ok := output["file_config"].(map[string]interface{}); ok {
@@ -69,6 +167,74 @@ func (e *ExtensionDefinition) GetFileConfig() map[string]interface{} {
 	return nil
 }
 
+// GetTimeout returns the configured timeout or DefaultExtensionTimeout
+func (e *ExtensionDefinition) GetTimeout() (time.Duration, error) {
+	if e.Timeout == "" {
+		return DefaultExtensionTimeout, nil
+	}
+	timeout, err := time.ParseDuration(e.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout format: %w", err)
+	}
+	return timeout, nil
+}
+
+// IsHTTP reports whether the operations of an extension are HTTP requests
+func (e *ExtensionDefinition) IsHTTP() bool {
+	return e.Type == ExtensionTypeHTTP
+}
+
+// executableHash returns the hash of the executable, empty for http
+// extensions which only depend on their config
+func (e *ExtensionDefinition) executableHash() (string, error) {
+	if e.IsHTTP() {
+		return "", nil
+	}
+	return ComputeHash(e.Executable)
+}
+
+// defaultType sets the type of configs written before types existed: http
+// when an operation has a url, executable otherwise
+func (e *ExtensionDefinition) defaultType() {
+	if e.Type != "" {
+		return
+	}
+	e.Type = ExtensionTypeExecutable
+	for _, op := range e.Operations {
+		if op.URL != "" {
+			e.Type = ExtensionTypeHTTP
+			return
+		}
+	}
+}
+
+// resolveExecutable makes a relative executable path relative to the
+// directory of the config file, as used by installed extensions
+func (e *ExtensionDefinition) resolveExecutable(configPath string) {
+	if e.Executable == "" || filepath.IsAbs(e.Executable) {
+		return
+	}
+	candidate := filepath.Join(filepath.Dir(configPath), e.Executable)
+	if _, err := os.Stat(candidate); err == nil {
+		e.Executable = candidate
+	}
+}
+
+// validateOutput checks the output format and embed mode
+func (e *ExtensionDefinition) validateOutput() error {
+	switch e.GetOutputFormat() {
+	case ExtensionFormatText, ExtensionFormatJSON:
+	default:
+		return fmt.Errorf("invalid output format %q (supported: text, json)", e.GetOutputFormat())
+	}
+	switch e.GetEmbedMode() {
+	case ExtensionEmbedAuto, ExtensionEmbedRaw, ExtensionEmbedFenced:
+	default:
+		return fmt.Errorf("invalid embed mode %q (supported: auto, raw, fenced)", e.GetEmbedMode())
+	}
+	return nil
+}
+
 func (e *ExtensionDefinition) IsCleanupEnabled() bool {
 	if fc := e.GetFileConfig(); fc != nil {
 		if cleanup, ok := fc["cleanup"].(bool); ok {
@@ -123,9 +289,9 @@ func (r *ExtensionRegistry) Register(configPath string) error {
 		return fmt.Errorf("extension name '%s' contains spaces - names must not contain spaces", ext.Name)
 	}
 
-	// Verify executable exists
-	if _, err := os.Stat(ext.Executable); err != nil {
-		return fmt.Errorf("executable not found: %w", err)
+	ext.defaultType()
+	if err := r.validateExtensionDefinition(&ext); err != nil {
+		return err
 	}
 
 	// Get absolute path to config
@@ -133,10 +299,18 @@ func (r *ExtensionRegistry) Register(configPath string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
+	ext.resolveExecutable(absPath)
+
+	// Verify executable exists
+	if !ext.IsHTTP() {
+		if _, err := os.Stat(ext.Executable); err != nil {
+			return fmt.Errorf("executable not found: %w", err)
+		}
+	}
 
 	// Calculate hashes
 	configHash := ComputeStringHash(string(data))
-	executableHash, err := ComputeHash(ext.Executable)
+	executableHash, err := ext.executableHash()
 	if err != nil {
 		return fmt.Errorf("failed to hash executable: %w", err)
 	}
@@ -156,11 +330,14 @@ func (r *ExtensionRegistry) validateExtensionDefinition(ext *ExtensionDefinition
 	if ext.Name == "" {
 		return fmt.Errorf("extension name is required")
 	}
-	if ext.Executable == "" {
+	if ext.Executable == "" && !ext.IsHTTP() {
 		return fmt.Errorf("executable path is required")
 	}
-	if ext.Type == "" {
-		return fmt.Errorf("extension type is required")
+	if len(ext.Operations) == 0 {
+		return fmt.Errorf("at least one operation must be defined")
+	}
+	if ext.Retries < 0 {
+		return fmt.Errorf("retries must not be negative")
 	}
 
 	// Validate timeout format
@@ -170,13 +347,29 @@ func (r *ExtensionRegistry) validateExtensionDefinition(ext *ExtensionDefinition
 		}
 	}
 
-	// Validate operations
-	if len(ext.Operations) == 0 {
-		return fmt.Errorf("at least one operation must be defined")
+	if err := ext.validateOutput(); err != nil {
+		return err
 	}
+
+	// Validate operations
 	for name, op := range ext.Operations {
-		if op.CmdTemplate == "" {
-			return fmt.Errorf("command template is required for operation %s", name)
+		if ext.IsHTTP() {
+			if op.URL == "" {
+				return fmt.Errorf("url is required for operation %s", name)
+			}
+			if op.CmdTemplate != "" || len(op.Args) > 0 {
+				return fmt.Errorf("operation %s of an http extension cannot have cmd_template or args", name)
+			}
+			continue
+		}
+		if op.URL != "" {
+			return fmt.Errorf("operation %s has a url but extension type is not %s", name, ExtensionTypeHTTP)
+		}
+		if op.CmdTemplate == "" && len(op.Args) == 0 {
+			return fmt.Errorf("command template or args are required for operation %s", name)
+		}
+		if op.CmdTemplate != "" && len(op.Args) > 0 {
+			return fmt.Errorf("operation %s cannot have both cmd_template and args", name)
 		}
 	}
 
@@ -217,9 +410,11 @@ func (r *ExtensionRegistry) Verify(name string) error {
 	if err := yaml.Unmarshal(data, &ext); err != nil {
 		return fmt.Errorf("failed to parse config file: %w", err)
 	}
+	ext.defaultType()
+	ext.resolveExecutable(entry.ConfigPath)
 
 	// Verify executable hash
-	currentExecutableHash, err := ComputeHash(ext.Executable)
+	currentExecutableHash, err := ext.executableHash()
 	if err != nil {
 		return fmt.Errorf("failed to verify executable: %w", err)
 	}
@@ -228,7 +423,7 @@ func (r *ExtensionRegistry) Verify(name string) error {
 		return fmt.Errorf("executable hash mismatch for %s", name)
 	}
 
-	return nil
+	return entry.verifyFiles(name)
 }
 
 func (r *ExtensionRegistry) GetExtension(name string) (*ExtensionDefinition, error) {
@@ -254,9 +449,11 @@ func (r *ExtensionRegistry) GetExtension(name string) (*ExtensionDefinition, err
 	if err := yaml.Unmarshal(data, &ext); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	ext.defaultType()
+	ext.resolveExecutable(entry.ConfigPath)
 
 	// Verify executable hash
-	currentExecHash, err := ComputeHash(ext.Executable)
+	currentExecHash, err := ext.executableHash()
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify executable: %w", err)
 	}
@@ -264,6 +461,9 @@ func (r *ExtensionRegistry) GetExtension(name string) (*ExtensionDefinition, err
 	if currentExecHash != entry.ExecutableHash {
 		return nil, fmt.Errorf("executable hash mismatch for %s", name)
 	}
+	if err := entry.verifyFiles(name); err != nil {
+		return nil, err
+	}
 
 	return &ext, nil
 }
@@ -3,6 +3,7 @@ package template
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -73,3 +74,61 @@ operations:
 		}
 	})
 }
+
+func TestRegisterWithoutType(t *testing.T) {
+	tmpDir := t.TempDir()
+	execPath := filepath.Join(tmpDir, "test-exec.sh")
+	if err := os.WriteFile(execPath, []byte("#!/bin/bash\necho \"test\""), 0755); err != nil {
+		t.Fatalf("Failed to create test executable: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   string
+		wantType string
+	}{
+		{
+			name:     "executable",
+			config:   "name: legacy\nexecutable: " + execPath + "\noperations:\n  test:\n    cmd_template: \"{{executable}}\"\n",
+			wantType: ExtensionTypeExecutable,
+		},
+		{
+			name:     "http",
+			config:   "name: legacy\noperations:\n  get:\n    url: http://localhost/{{value}}\n",
+			wantType: ExtensionTypeHTTP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configDir := t.TempDir()
+			configPath := filepath.Join(configDir, "legacy.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+				t.Fatalf("Failed to create test config: %v", err)
+			}
+
+			registry := NewExtensionRegistry(configDir)
+			if err := registry.Register(configPath); err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+			ext, err := registry.GetExtension("legacy")
+			if err != nil {
+				t.Fatalf("GetExtension() error = %v", err)
+			}
+			if ext.Type != tt.wantType {
+				t.Errorf("Type = %q, want %q", ext.Type, tt.wantType)
+			}
+		})
+	}
+
+	t.Run("no operations", func(t *testing.T) {
+		configDir := t.TempDir()
+		configPath := filepath.Join(configDir, "legacy.yaml")
+		if err := os.WriteFile(configPath, []byte("name: legacy\nexecutable: "+execPath+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test config: %v", err)
+		}
+		err := NewExtensionRegistry(configDir).Register(configPath)
+		if err == nil || !strings.Contains(err.Error(), "at least one operation") {
+			t.Errorf("Register() error = %v, want missing operations", err)
+		}
+	})
+}
@@ -1,44 +1,200 @@
 // Package template provides URL fetching operations for the template system.
-// Security Note: This plugin makes outbound HTTP requests. Use with caution
-// and consider implementing URL allowlists in production.
+// Security Note: This plugin makes outbound HTTP requests. Hosts can be
+// restricted with FETCH_ALLOWED_HOSTS and FETCH_DENIED_HOSTS, and private
+// network addresses are refused unless FETCH_ALLOW_PRIVATE_IPS is set.
 package template
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 	"unicode/utf8"
+
+	"github.com/danielmiessler/fabric/plugins/tools/converter"
 )
 
 const (
 	// MaxContentSize limits response size to 1MB to prevent memory issues
 	MaxContentSize = 1024 * 1024
 
+	// DefaultFetchTimeout bounds a whole request including the body
+	DefaultFetchTimeout = 30 * time.Second
+
 	// UserAgent identifies the client in HTTP requests
 	UserAgent = "Fabric-Fetch/1.0"
 )
 
+// Environment variables configuring the fetch plugin, usually set in
+// ~/.config/fabric/.env
+const (
+	FetchAllowedHostsEnv = "FETCH_ALLOWED_HOSTS"     // comma separated, subdomains included
+	FetchDeniedHostsEnv  = "FETCH_DENIED_HOSTS"      // comma separated, subdomains included
+	FetchAllowPrivateEnv = "FETCH_ALLOW_PRIVATE_IPS" // true to allow loopback and private networks
+	FetchCacheTTLEnv     = "FETCH_CACHE_TTL"         // duration such as 1h, caching is off when unset
+	FetchMaxSizeEnv      = "FETCH_MAX_SIZE"          // maximum response size in bytes
+	FetchTimeoutEnv      = "FETCH_TIMEOUT"           // duration such as 10s
+	// FetchHeadersEnvPrefix followed by the host in upper case with dots and
+	// dashes as underscores holds extra headers for that host, one
+	// "Name: value" per line, e.g. FETCH_HEADERS_API_GITHUB_COM
+	FetchHeadersEnvPrefix = "FETCH_HEADERS_"
+)
+
+// FetchConfig holds the restrictions and settings of the fetch plugin
+type FetchConfig struct {
+	AllowedHosts []string
+	DeniedHosts  []string
+	AllowPrivate bool
+	CacheDir     string
+	CacheTTL     time.Duration
+	MaxSize      int64
+	Timeout      time.Duration
+	// Headers are keyed by host, see FetchHeadersEnvPrefix
+	Headers map[string]http.Header
+}
+
+// NewFetchConfigFromEnv reads the FETCH_* environment variables
+func NewFetchConfigFromEnv() (ret *FetchConfig, err error) {
+	ret = &FetchConfig{
+		AllowedHosts: splitHosts(os.Getenv(FetchAllowedHostsEnv)),
+		DeniedHosts:  splitHosts(os.Getenv(FetchDeniedHostsEnv)),
+		MaxSize:      MaxContentSize,
+		Timeout:      DefaultFetchTimeout,
+		Headers:      make(map[string]http.Header),
+	}
+
+	if value := os.Getenv(FetchAllowPrivateEnv); value != "" {
+		if ret.AllowPrivate, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("fetch: invalid %s: %v", FetchAllowPrivateEnv, err)
+		}
+	}
+	if value := os.Getenv(FetchCacheTTLEnv); value != "" {
+		if ret.CacheTTL, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("fetch: invalid %s: %v", FetchCacheTTLEnv, err)
+		}
+		if ret.CacheTTL > 0 {
+			var cacheDir string
+			if cacheDir, err = os.UserCacheDir(); err != nil {
+				return nil, fmt.Errorf("fetch: could not determine cache directory: %v", err)
+			}
+			ret.CacheDir = filepath.Join(cacheDir, "fabric", "fetch")
+		}
+	}
+	if value := os.Getenv(FetchMaxSizeEnv); value != "" {
+		if ret.MaxSize, err = strconv.ParseInt(value, 10, 64); err != nil || ret.MaxSize <= 0 {
+			return nil, fmt.Errorf("fetch: invalid %s %q", FetchMaxSizeEnv, value)
+		}
+	}
+	if value := os.Getenv(FetchTimeoutEnv); value != "" {
+		if ret.Timeout, err = time.ParseDuration(value); err != nil || ret.Timeout <= 0 {
+			return nil, fmt.Errorf("fetch: invalid %s %q", FetchTimeoutEnv, value)
+		}
+	}
+
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, FetchHeadersEnvPrefix) {
+			continue
+		}
+		header := make(http.Header)
+		for _, line := range strings.Split(value, "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			key, val, ok := strings.Cut(line, ":")
+			if !ok {
+				return nil, fmt.Errorf("fetch: invalid header %q in %s, expected Name: value", line, name)
+			}
+			header.Add(strings.TrimSpace(key), strings.TrimSpace(val))
+		}
+		ret.Headers[strings.TrimPrefix(name, FetchHeadersEnvPrefix)] = header
+	}
+	return
+}
+
+// headersFor returns the configured headers of a host
+func (c *FetchConfig) headersFor(host string) http.Header {
+	key := strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToUpper(host))
+	return c.Headers[key]
+}
+
+// checkHost applies the deny and allow lists
+func (c *FetchConfig) checkHost(host string) error {
+	if matchHost(host, c.DeniedHosts) {
+		return fmt.Errorf("fetch: host %s is denied", host)
+	}
+	if len(c.AllowedHosts) > 0 && !matchHost(host, c.AllowedHosts) {
+		return fmt.Errorf("fetch: host %s is not in %s", host, FetchAllowedHostsEnv)
+	}
+	return nil
+}
+
 // FetchPlugin provides HTTP fetching capabilities with safety constraints:
 // - Only text content types allowed
-// - Size limited to MaxContentSize
+// - Size limited to MaxContentSize or FETCH_MAX_SIZE
+// - Host allow and deny lists, no private addresses by default
 // - UTF-8 validation
 // - Null byte checking
-type FetchPlugin struct{}
+type FetchPlugin struct {
+	// Config is read from the environment on every call when nil
+	Config *FetchConfig
+}
+
+// Name returns the template namespace of the plugin
+func (p *FetchPlugin) Name() string {
+	return "fetch"
+}
+
+// Description returns a short description of the plugin
+func (p *FetchPlugin) Description() string {
+	return "HTTP fetching of text content"
+}
+
+// Operations lists the supported fetch operations
+func (p *FetchPlugin) Operations() []Operation {
+	return []Operation{
+		{Name: "get", Usage: pluginUsage("fetch", "get", "URL"), Description: "Text content of a URL"},
+		{Name: "html", Usage: pluginUsage("fetch", "html", "URL"), Description: "Readable main text of an HTML page"},
+	}
+}
 
 // Apply executes fetch operations:
 //   - get:URL: Fetches content from URL, returns text content
-func (p *FetchPlugin) Apply(operation string, value string) (string, error) {
+//   - html:URL: Fetches a page and extracts its readable text
+func (p *FetchPlugin) Apply(operation string, value string) (ret string, err error) {
 	debugf("Fetch: operation=%q value=%q", operation, value)
 
 	switch operation {
-	case "get":
-		return p.fetch(value)
+	case "get", "html":
 	default:
-		return "", fmt.Errorf("fetch: unknown operation %q (supported: get)", operation)
+		return "", fmt.Errorf("fetch: unknown operation %q (supported: get, html)", operation)
+	}
+
+	config := p.Config
+	if config == nil {
+		if config, err = NewFetchConfigFromEnv(); err != nil {
+			return
+		}
+	}
+	if ret, err = p.fetch(config, value); err != nil || operation == "get" {
+		return
+	}
+	if ret, err = converter.HtmlReadability(ret); err != nil {
+		return "", fmt.Errorf("fetch: could not extract readable text: %v", err)
 	}
+	return strings.TrimSpace(ret), nil
 }
 
 // isTextContent checks if the content type is text-based
@@ -80,55 +236,189 @@ func (p *FetchPlugin) validateTextContent(content []byte) error {
 	return nil
 }
 
-// fetch retrieves content from a URL with safety checks
-func (p *FetchPlugin) fetch(urlStr string) (string, error) {
+// fetch retrieves content from a URL with safety checks, using the cache
+// when it is enabled
+func (p *FetchPlugin) fetch(config *FetchConfig, urlStr string) (string, error) {
 	debugf("Fetch: requesting URL %q", urlStr)
 
-	client := &http.Client{}
 	req, err := http.NewRequest("GET", urlStr, nil)
 	if err != nil {
 		return "", fmt.Errorf("fetch: error creating request: %v", err)
 	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return "", fmt.Errorf("fetch: unsupported protocol scheme %q", req.URL.Scheme)
+	}
+	host := req.URL.Hostname()
+	if err = config.checkHost(host); err != nil {
+		return "", err
+	}
+
+	cachePath := ""
+	if config.CacheDir != "" && config.CacheTTL > 0 {
+		cachePath = fetchCachePath(config.CacheDir, req.URL, config.headersFor(host))
+		if info, statErr := os.Stat(cachePath); statErr == nil && time.Since(info.ModTime()) < config.CacheTTL {
+			if content, readErr := os.ReadFile(cachePath); readErr == nil {
+				debugf("Fetch: cache hit %s", cachePath)
+				return string(content), nil
+			}
+		}
+	}
+
 	req.Header.Set("User-Agent", UserAgent)
+	for key, values := range config.headersFor(host) {
+		req.Header[key] = values
+	}
+
+	content, err := p.do(config, req)
+	if err != nil {
+		return "", err
+	}
+
+	if cachePath != "" {
+		if err = os.MkdirAll(filepath.Dir(cachePath), 0700); err == nil {
+			err = os.WriteFile(cachePath, content, 0600)
+		}
+		if err != nil {
+			debugf("Fetch: could not write cache: %v", err)
+		}
+	}
+
+	debugf("Fetch: operation completed successfully, read %d bytes", len(content))
+	return string(content), nil
+}
+
+// do sends the request and validates the response
+func (p *FetchPlugin) do(config *FetchConfig, req *http.Request) ([]byte, error) {
+	client := newFetchClient(config)
+	ctx, cancel := context.WithTimeout(req.Context(), config.Timeout)
+	defer cancel()
 
-	resp, err := client.Do(req)
+	resp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
-		return "", fmt.Errorf("fetch: error fetching URL: %v", err)
+		return nil, fmt.Errorf("fetch: error fetching URL: %v", err)
 	}
 	defer resp.Body.Close()
 
 	debugf("Fetch: got response status=%q", resp.Status)
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("fetch: HTTP error: %d - %s", resp.StatusCode, resp.Status)
+		return nil, fmt.Errorf("fetch: HTTP error: %d - %s", resp.StatusCode, resp.Status)
 	}
 
-	if contentLength := resp.ContentLength; contentLength > MaxContentSize {
-		return "", fmt.Errorf("fetch: content too large: %d bytes (max %d bytes)",
-			contentLength, MaxContentSize)
+	if contentLength := resp.ContentLength; contentLength > config.MaxSize {
+		return nil, fmt.Errorf("fetch: content too large: %d bytes (max %d bytes)",
+			contentLength, config.MaxSize)
 	}
 
 	contentType := resp.Header.Get("Content-Type")
 	debugf("Fetch: content-type=%q", contentType)
 	if !p.isTextContent(contentType) {
-		return "", fmt.Errorf("fetch: unsupported content type %q - only text content allowed",
+		return nil, fmt.Errorf("fetch: unsupported content type %q - only text content allowed",
 			contentType)
 	}
 
 	debugf("Fetch: reading response body")
-	limitReader := io.LimitReader(resp.Body, MaxContentSize+1)
+	limitReader := io.LimitReader(resp.Body, config.MaxSize+1)
 	content, err := io.ReadAll(limitReader)
 	if err != nil {
-		return "", fmt.Errorf("fetch: error reading response: %v", err)
+		return nil, fmt.Errorf("fetch: error reading response: %v", err)
 	}
 
-	if len(content) > MaxContentSize {
-		return "", fmt.Errorf("fetch: content too large: exceeds %d bytes", MaxContentSize)
+	if int64(len(content)) > config.MaxSize {
+		return nil, fmt.Errorf("fetch: content too large: exceeds %d bytes", config.MaxSize)
 	}
 
 	if err := p.validateTextContent(content); err != nil {
-		return "", err
+		return nil, err
 	}
+	return content, nil
+}
 
-	debugf("Fetch: operation completed successfully, read %d bytes", len(content))
-	return string(content), nil
+// newFetchClient creates a client that checks redirect targets against the
+// host lists and refuses private addresses when connecting, so DNS answers
+// cannot bypass the check
+func newFetchClient(config *FetchConfig) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		dialer := &net.Dialer{Timeout: config.Timeout}
+		if !config.AllowPrivate {
+			dialer.Control = func(network, address string, _ syscall.RawConn) error {
+				ipStr, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(ipStr); ip == nil || isPrivateIP(ip) {
+					return fmt.Errorf("fetch: %s resolves to private address %s (set %s=true to allow)",
+						host, ipStr, FetchAllowPrivateEnv)
+				}
+				return nil
+			}
+		}
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("fetch: stopped after 10 redirects")
+			}
+			host := req.URL.Hostname()
+			if err := config.checkHost(host); err != nil {
+				return err
+			}
+			// the headers of the original request are copied to the redirect,
+			// configured ones must not reach another host
+			if original := via[0].URL.Hostname(); host != original {
+				for key := range config.headersFor(original) {
+					req.Header.Del(key)
+				}
+				for key, values := range config.headersFor(host) {
+					req.Header[key] = values
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// isPrivateIP reports loopback, private, link-local and unspecified addresses
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// matchHost reports whether host equals an entry or is a subdomain of one
+func matchHost(host string, hosts []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, entry := range hosts {
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitHosts parses a comma separated host list; *.example.com is the same
+// as example.com
+func splitHosts(value string) (ret []string) {
+	for _, host := range strings.Split(value, ",") {
+		host = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(host), "*."))
+		if host != "" {
+			ret = append(ret, host)
+		}
+	}
+	return
+}
+
+// fetchCachePath maps a URL and the headers sent with it to its cache file,
+// so responses fetched with other credentials are not reused
+func fetchCachePath(cacheDir string, u *url.URL, header http.Header) string {
+	hash := sha256.New()
+	hash.Write([]byte(u.String()))
+	_ = header.Write(hash)
+	return filepath.Join(cacheDir, hex.EncodeToString(hash.Sum(nil)))
 }
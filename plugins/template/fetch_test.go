@@ -1,9 +1,15 @@
 package template
 
 import (
+	"fmt"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestFetchPlugin(t *testing.T) {
@@ -70,3 +76,260 @@ func TestFetchPlugin(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchPluginConfig(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/auth":
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, r.Header.Get("Authorization"))
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, "<html><body><nav>menu</nav><article><p>Main text of the page.</p></article></body></html>")
+		case "/large":
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, strings.Repeat("x", 100))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, "late")
+		case "/redirect":
+			http.Redirect(w, r, "http://denied.example/", http.StatusFound)
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprintf(w, "response %d", requests)
+		}
+	}))
+	defer server.Close()
+
+	newConfig := func() *FetchConfig {
+		return &FetchConfig{
+			AllowPrivate: true,
+			MaxSize:      MaxContentSize,
+			Timeout:      DefaultFetchTimeout,
+			Headers:      map[string]http.Header{},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		operation   string
+		path        string
+		configure   func(c *FetchConfig)
+		want        string
+		errContains string
+	}{
+		{
+			name:        "private address blocked by default",
+			path:        "/",
+			configure:   func(c *FetchConfig) { c.AllowPrivate = false },
+			errContains: "private address",
+		},
+		{
+			name:        "allow list does not permit private addresses",
+			path:        "/",
+			configure:   func(c *FetchConfig) { c.AllowPrivate, c.AllowedHosts = false, []string{"127.0.0.1"} },
+			errContains: "private address",
+		},
+		{
+			name:        "host not in allow list",
+			path:        "/",
+			configure:   func(c *FetchConfig) { c.AllowedHosts = []string{"example.com"} },
+			errContains: "not in FETCH_ALLOWED_HOSTS",
+		},
+		{
+			name:        "denied host",
+			path:        "/",
+			configure:   func(c *FetchConfig) { c.DeniedHosts = []string{"127.0.0.1"} },
+			errContains: "is denied",
+		},
+		{
+			name:        "redirect to denied host",
+			path:        "/redirect",
+			configure:   func(c *FetchConfig) { c.DeniedHosts = []string{"denied.example"} },
+			errContains: "is denied",
+		},
+		{
+			name:      "per host headers",
+			path:      "/auth",
+			configure: func(c *FetchConfig) { c.Headers["127_0_0_1"] = http.Header{"Authorization": {"Bearer secret"}} },
+			want:      "Bearer secret",
+		},
+		{
+			name:        "size limit",
+			path:        "/large",
+			configure:   func(c *FetchConfig) { c.MaxSize = 10 },
+			errContains: "content too large",
+		},
+		{
+			name:        "timeout",
+			path:        "/slow",
+			configure:   func(c *FetchConfig) { c.Timeout = 50 * time.Millisecond },
+			errContains: "error fetching URL",
+		},
+		{
+			name:      "html mode extracts readable text",
+			operation: "html",
+			path:      "/page",
+			want:      "Main text of the page.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newConfig()
+			if tt.configure != nil {
+				tt.configure(config)
+			}
+			operation := tt.operation
+			if operation == "" {
+				operation = "get"
+			}
+
+			got, err := (&FetchPlugin{Config: config}).Apply(operation, server.URL+tt.path)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("got %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchPluginCache(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "response %d", requests)
+	}))
+	defer server.Close()
+
+	config := &FetchConfig{
+		AllowPrivate: true,
+		CacheDir:     t.TempDir(),
+		CacheTTL:     time.Hour,
+		MaxSize:      MaxContentSize,
+		Timeout:      DefaultFetchTimeout,
+	}
+	plugin := &FetchPlugin{Config: config}
+
+	for i := 0; i < 2; i++ {
+		got, err := plugin.Apply("get", server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "response 1" {
+			t.Errorf("request %d: got %q, want cached %q", i+1, got, "response 1")
+		}
+	}
+
+	// expire the entry
+	cachePath := fetchCachePath(config.CacheDir, mustParseURL(t, server.URL), nil)
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cachePath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := plugin.Apply("get", server.URL); got != "response 2" {
+		t.Errorf("after expiry got %q, want %q", got, "response 2")
+	}
+
+	// other credentials do not reuse the cached response
+	config.Headers = map[string]http.Header{"127_0_0_1": {"X-Api-Key": {"other"}}}
+	if got, _ := plugin.Apply("get", server.URL); got != "response 3" {
+		t.Errorf("with other headers got %q, want %q", got, "response 3")
+	}
+}
+
+func TestFetchPluginRedirectHeaders(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "key=%q other=%q", r.Header.Get("X-Api-Key"), r.Header.Get("X-Other"))
+	}))
+	defer target.Close()
+
+	// the same server under another host name
+	targetURL := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, targetURL+"/", http.StatusFound)
+	}))
+	defer origin.Close()
+
+	config := &FetchConfig{
+		AllowPrivate: true,
+		MaxSize:      MaxContentSize,
+		Timeout:      DefaultFetchTimeout,
+		Headers: map[string]http.Header{
+			"127_0_0_1": {"X-Api-Key": {"secret"}},
+			"LOCALHOST": {"X-Other": {"for localhost"}},
+		},
+	}
+
+	got, err := (&FetchPlugin{Config: config}).Apply("get", origin.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `key="" other="for localhost"`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewFetchConfigFromEnv(t *testing.T) {
+	t.Setenv(FetchAllowedHostsEnv, "*.GitHub.com, example.org")
+	t.Setenv(FetchDeniedHostsEnv, "gist.github.com")
+	t.Setenv(FetchAllowPrivateEnv, "true")
+	t.Setenv(FetchCacheTTLEnv, "10m")
+	t.Setenv(FetchMaxSizeEnv, "2048")
+	t.Setenv(FetchTimeoutEnv, "5s")
+	t.Setenv(FetchHeadersEnvPrefix+"API_GITHUB_COM", "Authorization: Bearer abc\nAccept: application/json")
+
+	config, err := NewFetchConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(config.AllowedHosts, []string{"github.com", "example.org"}) {
+		t.Errorf("AllowedHosts = %v", config.AllowedHosts)
+	}
+	if !config.AllowPrivate || config.CacheTTL != 10*time.Minute || config.MaxSize != 2048 || config.Timeout != 5*time.Second {
+		t.Errorf("unexpected config %+v", config)
+	}
+	if config.CacheDir == "" {
+		t.Error("CacheDir should be set when caching is enabled")
+	}
+	headers := config.headersFor("api.github.com")
+	if headers.Get("Authorization") != "Bearer abc" || headers.Get("Accept") != "application/json" {
+		t.Errorf("headers = %v", headers)
+	}
+	if err = config.checkHost("api.github.com"); err != nil {
+		t.Errorf("api.github.com should be allowed: %v", err)
+	}
+	if err = config.checkHost("gist.github.com"); err == nil {
+		t.Error("gist.github.com should be denied")
+	}
+	if err = config.checkHost("evilgithub.com"); err == nil {
+		t.Error("evilgithub.com should not match github.com")
+	}
+
+	t.Setenv(FetchTimeoutEnv, "soon")
+	if _, err = NewFetchConfigFromEnv(); err == nil {
+		t.Error("expected error for invalid timeout")
+	}
+}
+
+func mustParseURL(t *testing.T, s string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
@@ -1,13 +1,16 @@
 // Package template provides file system operations for the template system.
 // Security Note: This plugin provides access to the local filesystem.
-// Consider carefully which paths to allow access to in production.
+// Restrict it to a set of directories with FILE_ALLOWED_ROOTS.
 package template
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -16,14 +19,70 @@ import (
 // MaxFileSize defines the maximum file size that can be read (1MB)
 const MaxFileSize = 1 * 1024 * 1024
 
+// DefaultMaxTotalFileSize is the number of bytes all file operations of a
+// single template may return together (10MB)
+const DefaultMaxTotalFileSize = 10 * 1024 * 1024
+
+// Environment variables configuring the file plugin, usually set in
+// ~/.config/fabric/.env
+const (
+	FileAllowedRootsEnv  = "FILE_ALLOWED_ROOTS"  // directories separated by the OS path list separator
+	FileMaxSizeEnv       = "FILE_MAX_SIZE"       // maximum size of a single file in bytes
+	FileMaxTotalSizeEnv  = "FILE_MAX_TOTAL_SIZE" // byte budget of a template
+	fileGlobHeaderFormat = "==> %s <==\n"
+)
+
+// FileConfig holds the restrictions of the file plugin
+type FileConfig struct {
+	// AllowedRoots limits access to these directories when not empty
+	AllowedRoots []string
+	MaxSize      int64
+	MaxTotalSize int64
+}
+
+// NewFileConfigFromEnv reads the FILE_* environment variables
+func NewFileConfigFromEnv() (ret *FileConfig, err error) {
+	ret = &FileConfig{
+		MaxSize:      MaxFileSize,
+		MaxTotalSize: DefaultMaxTotalFileSize,
+	}
+	for _, root := range filepath.SplitList(os.Getenv(FileAllowedRootsEnv)) {
+		if root = strings.TrimSpace(root); root != "" {
+			ret.AllowedRoots = append(ret.AllowedRoots, root)
+		}
+	}
+	if value := os.Getenv(FileMaxSizeEnv); value != "" {
+		if ret.MaxSize, err = strconv.ParseInt(value, 10, 64); err != nil || ret.MaxSize <= 0 {
+			return nil, fmt.Errorf("file: invalid %s %q", FileMaxSizeEnv, value)
+		}
+	}
+	if value := os.Getenv(FileMaxTotalSizeEnv); value != "" {
+		if ret.MaxTotalSize, err = strconv.ParseInt(value, 10, 64); err != nil || ret.MaxTotalSize <= 0 {
+			return nil, fmt.Errorf("file: invalid %s %q", FileMaxTotalSizeEnv, value)
+		}
+	}
+	return
+}
+
 // FilePlugin provides filesystem operations with safety constraints:
 // - No directory traversal
-// - Size limits
+// - Optional allowed root directories, checked after resolving symlinks
+// - Size limits per file and per template
 // - Path sanitization
-type FilePlugin struct{}
+type FilePlugin struct {
+	// Config is read from the environment on every call when nil
+	Config *FileConfig
+	// used counts the bytes returned within one template, see Scope
+	used *int64
+}
+
+// Scope returns a plugin with its own byte budget for one template
+func (p *FilePlugin) Scope() Plugin {
+	return &FilePlugin{Config: p.Config, used: new(int64)}
+}
 
 // safePath validates and normalizes file paths
-func (p *FilePlugin) safePath(path string) (string, error) {
+func (p *FilePlugin) safePath(config *FileConfig, path string) (string, error) {
 	debugf("File: validating path %q", path)
 
 	// Basic security check - no path traversal
@@ -43,74 +102,162 @@ func (p *FilePlugin) safePath(path string) (string, error) {
 	// Clean the path
 	cleaned := filepath.Clean(path)
 	debugf("File: cleaned path %q", cleaned)
+
+	if err := p.checkRoots(config, cleaned); err != nil {
+		return "", err
+	}
 	return cleaned, nil
 }
 
-// Apply executes file operations:
-//   - read:PATH - Read entire file content
-//   - tail:PATH|N - Read last N lines
-//   - exists:PATH - Check if file exists
-//   - size:PATH - Get file size in bytes
-//   - modified:PATH - Get last modified time
-func (p *FilePlugin) Apply(operation string, value string) (string, error) {
-	debugf("File: operation=%q value=%q", operation, value)
-
-	switch operation {
-	case "tail":
-		parts := strings.Split(value, "|")
-		if len(parts) != 2 {
-			return "", fmt.Errorf("file: tail requires format path|lines")
-		}
-
-		path, err := p.safePath(parts[0])
+// checkRoots ensures the real location of path, after following symlinks,
+// is inside one of the allowed roots
+func (p *FilePlugin) checkRoots(config *FileConfig, path string) error {
+	if len(config.AllowedRoots) == 0 {
+		return nil
+	}
+	real, err := resolveSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("file: could not resolve %s: %v", path, err)
+	}
+	for _, root := range config.AllowedRoots {
+		realRoot, err := resolveSymlinks(root)
 		if err != nil {
-			return "", err
+			continue
+		}
+		if isWithin(realRoot, real) {
+			return nil
 		}
+	}
+	return fmt.Errorf("file: %s is outside of %s", path, FileAllowedRootsEnv)
+}
 
-		n, err := strconv.Atoi(parts[1])
+// rootsRelation reports whether the real location of path is inside one of
+// the allowed roots, or else a parent directory of one
+func (p *FilePlugin) rootsRelation(config *FileConfig, path string) (inside bool, parent bool) {
+	if len(config.AllowedRoots) == 0 {
+		return true, false
+	}
+	real, err := resolveSymlinks(path)
+	if err != nil {
+		return false, false
+	}
+	for _, root := range config.AllowedRoots {
+		realRoot, err := resolveSymlinks(root)
 		if err != nil {
-			return "", fmt.Errorf("file: invalid line count %q", parts[1])
+			continue
 		}
-
-		if n < 1 {
-			return "", fmt.Errorf("file: line count must be positive")
+		if isWithin(realRoot, real) {
+			return true, false
 		}
+		parent = parent || isWithin(real, realRoot)
+	}
+	return false, parent
+}
 
-		lines, err := p.lastNLines(path, n)
-		if err != nil {
+// isWithin reports whether path is dir or below it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveSymlinks returns the absolute real path. Missing trailing elements
+// are kept as they are, so paths of files that do not exist yet resolve too.
+func resolveSymlinks(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	var missing []string
+	for {
+		real, err := filepath.EvalSymlinks(abs)
+		if err == nil {
+			return filepath.Join(append([]string{real}, missing...)...), nil
+		}
+		if !os.IsNotExist(err) {
 			return "", err
 		}
-
-		result := strings.Join(lines, "\n")
-		debugf("File: tail returning %d lines", len(lines))
-		return result, nil
-
-	case "read":
-		path, err := p.safePath(value)
-		if err != nil {
+		parent := filepath.Dir(abs)
+		if parent == abs {
 			return "", err
 		}
+		missing = append([]string{filepath.Base(abs)}, missing...)
+		abs = parent
+	}
+}
 
-		info, err := os.Stat(path)
-		if err != nil {
-			return "", fmt.Errorf("file: could not stat file: %v", err)
+// consume charges n bytes to the template budget
+func (p *FilePlugin) consume(config *FileConfig, n int) error {
+	if p.used == nil {
+		if int64(n) > config.MaxTotalSize {
+			return fmt.Errorf("file: %d bytes exceed the budget of %d bytes", n, config.MaxTotalSize)
 		}
+		return nil
+	}
+	if *p.used+int64(n) > config.MaxTotalSize {
+		return fmt.Errorf("file: template exceeds the budget of %d bytes (set %s to raise it)",
+			config.MaxTotalSize, FileMaxTotalSizeEnv)
+	}
+	*p.used += int64(n)
+	return nil
+}
 
-		if info.Size() > MaxFileSize {
-			return "", fmt.Errorf("file: size %d exceeds limit of %d bytes",
-				info.Size(), MaxFileSize)
-		}
+// Name returns the template namespace of the plugin
+func (p *FilePlugin) Name() string {
+	return "file"
+}
 
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return "", fmt.Errorf("file: could not read: %v", err)
+// Description returns a short description of the plugin
+func (p *FilePlugin) Description() string {
+	return "Local file access"
+}
+
+// Operations lists the supported file operations
+func (p *FilePlugin) Operations() []Operation {
+	return []Operation{
+		{Name: "read", Usage: pluginUsage("file", "read", "PATH"), Description: "Entire file content"},
+		{Name: "head", Usage: pluginUsage("file", "head", "PATH|N"), Description: "First N lines of a file"},
+		{Name: "tail", Usage: pluginUsage("file", "tail", "PATH|N"), Description: "Last N lines of a file"},
+		{Name: "lines", Usage: pluginUsage("file", "lines", "PATH|START-END"), Description: "Lines START to END (END optional)"},
+		{Name: "glob", Usage: pluginUsage("file", "glob", "PATTERN"), Description: "Matching files concatenated with headers, ** matches directories"},
+		{Name: "tree", Usage: pluginUsage("file", "tree", "PATH|DEPTH"), Description: "Directory tree, DEPTH optional"},
+		{Name: "exists", Usage: pluginUsage("file", "exists", "PATH"), Description: "Whether the file exists (true/false)"},
+		{Name: "size", Usage: pluginUsage("file", "size", "PATH"), Description: "File size in bytes"},
+		{Name: "modified", Usage: pluginUsage("file", "modified", "PATH"), Description: "Last modification time in RFC3339"},
+	}
+}
+
+// Apply executes file operations:
+//   - read:PATH - Read entire file content
+//   - head:PATH|N - Read first N lines
+//   - tail:PATH|N - Read last N lines
+//   - lines:PATH|START-END - Read a range of lines
+//   - glob:PATTERN - Read all matching files
+//   - tree:PATH|DEPTH - List a directory
+//   - exists:PATH - Check if file exists
+//   - size:PATH - Get file size in bytes
+//   - modified:PATH - Get last modified time
+func (p *FilePlugin) Apply(operation string, value string) (ret string, err error) {
+	debugf("File: operation=%q value=%q", operation, value)
+
+	config := p.Config
+	if config == nil {
+		if config, err = NewFileConfigFromEnv(); err != nil {
+			return
 		}
+	}
 
-		debugf("File: read %d bytes", len(content))
-		return string(content), nil
+	switch operation {
+	case "read", "head", "tail", "lines", "glob", "tree":
+		if ret, err = p.read(config, operation, value); err != nil {
+			return "", err
+		}
+		if err = p.consume(config, len(ret)); err != nil {
+			return "", err
+		}
+		return
 
 	case "exists":
-		path, err := p.safePath(value)
+		path, err := p.safePath(config, value)
 		if err != nil {
 			return "", err
 		}
@@ -121,7 +268,7 @@ func (p *FilePlugin) Apply(operation string, value string) (string, error) {
 		return fmt.Sprintf("%t", exists), nil
 
 	case "size":
-		path, err := p.safePath(value)
+		path, err := p.safePath(config, value)
 		if err != nil {
 			return "", err
 		}
@@ -136,7 +283,7 @@ func (p *FilePlugin) Apply(operation string, value string) (string, error) {
 		return fmt.Sprintf("%d", size), nil
 
 	case "modified":
-		path, err := p.safePath(value)
+		path, err := p.safePath(config, value)
 		if err != nil {
 			return "", err
 		}
@@ -151,13 +298,176 @@ func (p *FilePlugin) Apply(operation string, value string) (string, error) {
 		return mtime, nil
 
 	default:
-		return "", fmt.Errorf("file: unknown operation %q (supported: read, tail, exists, size, modified)",
+		return "", fmt.Errorf("file: unknown operation %q (supported: read, head, tail, lines, glob, tree, exists, size, modified)",
 			operation)
 	}
 }
 
+// read runs the operations that return file content
+func (p *FilePlugin) read(config *FileConfig, operation string, value string) (string, error) {
+	switch operation {
+	case "head", "tail":
+		parts := strings.Split(value, "|")
+		if len(parts) != 2 {
+			return "", fmt.Errorf("file: %s requires format path|lines", operation)
+		}
+
+		path, err := p.safePath(config, parts[0])
+		if err != nil {
+			return "", err
+		}
+
+		n, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return "", fmt.Errorf("file: invalid line count %q", parts[1])
+		}
+
+		if n < 1 {
+			return "", fmt.Errorf("file: line count must be positive")
+		}
+
+		var lines []string
+		if operation == "head" {
+			lines, err = p.lineRange(config, path, 1, n)
+		} else {
+			lines, err = p.lastNLines(config, path, n)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		debugf("File: %s returning %d lines", operation, len(lines))
+		return strings.Join(lines, "\n"), nil
+
+	case "lines":
+		parts := strings.Split(value, "|")
+		if len(parts) != 2 {
+			return "", fmt.Errorf("file: lines requires format path|start-end")
+		}
+
+		path, err := p.safePath(config, parts[0])
+		if err != nil {
+			return "", err
+		}
+
+		start, end, err := parseLineRange(parts[1])
+		if err != nil {
+			return "", err
+		}
+
+		lines, err := p.lineRange(config, path, start, end)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(lines, "\n"), nil
+
+	case "glob":
+		return p.glob(config, value)
+
+	case "tree":
+		path, depthStr, hasDepth := strings.Cut(value, "|")
+		depth := -1
+		if hasDepth {
+			var err error
+			if depth, err = strconv.Atoi(depthStr); err != nil || depth < 1 {
+				return "", fmt.Errorf("file: invalid tree depth %q", depthStr)
+			}
+		}
+		path, err := p.safePath(config, path)
+		if err != nil {
+			return "", err
+		}
+		return p.tree(config, path, depth)
+
+	default:
+		path, err := p.safePath(config, value)
+		if err != nil {
+			return "", err
+		}
+
+		content, err := p.readFile(config, path)
+		if err != nil {
+			return "", err
+		}
+
+		debugf("File: read %d bytes", len(content))
+		return string(content), nil
+	}
+}
+
+// readFile reads a whole file within the size limit
+func (p *FilePlugin) readFile(config *FileConfig, path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("file: could not stat file: %v", err)
+	}
+
+	if info.Size() > config.MaxSize {
+		return nil, fmt.Errorf("file: size %d exceeds limit of %d bytes",
+			info.Size(), config.MaxSize)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("file: could not read: %v", err)
+	}
+	return content, nil
+}
+
+// parseLineRange parses "START-END" or "START-" into 1-based line numbers;
+// end is 0 for the end of the file
+func parseLineRange(spec string) (start int, end int, err error) {
+	startStr, endStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("file: invalid line range %q, expected start-end", spec)
+	}
+	if start, err = strconv.Atoi(startStr); err != nil || start < 1 {
+		return 0, 0, fmt.Errorf("file: invalid line range %q", spec)
+	}
+	if endStr != "" {
+		if end, err = strconv.Atoi(endStr); err != nil || end < start {
+			return 0, 0, fmt.Errorf("file: invalid line range %q", spec)
+		}
+	}
+	return start, end, nil
+}
+
+// lineRange returns lines start to end (inclusive, 0 for the last line)
+// without reading past end
+func (p *FilePlugin) lineRange(config *FileConfig, path string, start, end int) ([]string, error) {
+	debugf("File: reading lines %d-%d from %q", start, end, path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("file: could not open: %v", err)
+	}
+	defer file.Close()
+
+	var lines []string
+	var size int64
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if lineNo < start {
+			continue
+		}
+		if end > 0 && lineNo > end {
+			break
+		}
+		size += int64(len(scanner.Bytes())) + 1
+		if size > config.MaxSize {
+			return nil, fmt.Errorf("file: lines exceed limit of %d bytes", config.MaxSize)
+		}
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("file: error reading: %v", err)
+	}
+	return lines, nil
+}
+
 // lastNLines returns the last n lines from a file
-func (p *FilePlugin) lastNLines(path string, n int) ([]string, error) {
+func (p *FilePlugin) lastNLines(config *FileConfig, path string, n int) ([]string, error) {
 	debugf("File: reading last %d lines from %q", n, path)
 
 	file, err := os.Open(path)
@@ -171,9 +481,9 @@ func (p *FilePlugin) lastNLines(path string, n int) ([]string, error) {
 		return nil, fmt.Errorf("file: could not stat: %v", err)
 	}
 
-	if info.Size() > MaxFileSize {
+	if info.Size() > config.MaxSize {
 		return nil, fmt.Errorf("file: size %d exceeds limit of %d bytes",
-			info.Size(), MaxFileSize)
+			info.Size(), config.MaxSize)
 	}
 
 	lines := make([]string, 0, n)
@@ -195,3 +505,187 @@ func (p *FilePlugin) lastNLines(path string, n int) ([]string, error) {
 	debugf("File: read %d lines total, returning last %d", lineCount, len(lines))
 	return lines, nil
 }
+
+// glob concatenates the regular files matching pattern, each preceded by a
+// "==> path <==" header. Binary files are skipped.
+func (p *FilePlugin) glob(config *FileConfig, pattern string) (string, error) {
+	// the pattern itself cannot be resolved, the roots are checked while walking
+	pattern, err := p.safePath(&FileConfig{}, pattern)
+	if err != nil {
+		return "", err
+	}
+	pattern = filepath.ToSlash(pattern)
+
+	// walk from the longest directory prefix without wildcards
+	base := "."
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if strings.ContainsAny(segment, "*?[") {
+			base = strings.Join(segments[:i], "/")
+			if base == "" && strings.HasPrefix(pattern, "/") {
+				base = "/"
+			} else if base == "" {
+				base = "."
+			}
+			break
+		}
+		if i == len(segments)-1 {
+			base = pattern
+		}
+	}
+
+	// only directories inside the roots or leading to one are walked, and
+	// files outside of them are left out
+	if inside, parent := p.rootsRelation(config, filepath.FromSlash(base)); !inside && !parent {
+		return "", fmt.Errorf("file: %s is outside of %s", base, FileAllowedRootsEnv)
+	}
+	var matches []string
+	err = filepath.WalkDir(filepath.FromSlash(base), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != filepath.FromSlash(base) {
+				return fs.SkipDir // unreadable subdirectory
+			}
+			return err
+		}
+		inside, parent := p.rootsRelation(config, path)
+		if d.IsDir() {
+			if !inside && !parent {
+				return fs.SkipDir
+			}
+			// without ** only the directories the pattern names are entered
+			if path != filepath.FromSlash(base) && !matchGlobDir(pattern, filepath.ToSlash(path)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if inside && matchGlob(pattern, filepath.ToSlash(path)) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("file: glob %s: %v", pattern, err)
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("file: no files match %s", pattern)
+	}
+	sort.Strings(matches)
+
+	var sb strings.Builder
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		content, err := p.readFile(config, path)
+		if err != nil {
+			return "", err
+		}
+		if bytes.IndexByte(content, 0) >= 0 {
+			debugf("File: glob skipping binary file %q", path)
+			continue
+		}
+		if int64(sb.Len()+len(content)) > config.MaxTotalSize {
+			return "", fmt.Errorf("file: files matching %s exceed the budget of %d bytes", pattern, config.MaxTotalSize)
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, fileGlobHeaderFormat, path)
+		sb.Write(content)
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// matchGlob matches a slash separated path against pattern, where a **
+// segment matches any number of directories
+func matchGlob(pattern, path string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(path, "/"))
+}
+
+// matchGlobDir reports whether files matching pattern may be found inside
+// the slash separated directory dir
+func matchGlobDir(pattern, dir string) bool {
+	segments, dirSegments := strings.Split(pattern, "/"), strings.Split(dir, "/")
+	for i, segment := range dirSegments {
+		if i < len(segments) && segments[i] == "**" {
+			return true
+		}
+		if i >= len(segments)-1 {
+			return false
+		}
+		if ok, err := filepath.Match(segments[i], segment); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func matchSegments(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, err := filepath.Match(pattern[0], path[0]); err != nil || !ok {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
+
+// tree lists a directory like the tree command; depth < 0 means unlimited
+func (p *FilePlugin) tree(config *FileConfig, root string, depth int) (string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return "", fmt.Errorf("file: could not stat file: %v", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("file: %s is not a directory", root)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(root + "\n")
+	var walk func(dir, prefix string, level int) error
+	walk = func(dir, prefix string, level int) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("file: could not read directory: %v", err)
+		}
+		for i, entry := range entries {
+			connector, childPrefix := "├── ", "│   "
+			if i == len(entries)-1 {
+				connector, childPrefix = "└── ", "    "
+			}
+			name := entry.Name()
+			if entry.IsDir() {
+				name += "/"
+			}
+			sb.WriteString(prefix + connector + name + "\n")
+			if int64(sb.Len()) > config.MaxTotalSize {
+				return fmt.Errorf("file: tree of %s exceeds the budget of %d bytes", root, config.MaxTotalSize)
+			}
+			if entry.IsDir() && (depth < 0 || level < depth) {
+				if err := walk(filepath.Join(dir, entry.Name()), prefix+childPrefix, level+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err = walk(root, "", 1); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
@@ -1,6 +1,7 @@
 package template
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -150,3 +151,184 @@ func TestFilePlugin(t *testing.T) {
 		})
 	}
 }
+
+func TestFilePluginContentOperations(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"a.md":           "# A\n",
+		"docs/b.md":      "# B\n",
+		"docs/deep/c.md": "# C",
+		"docs/d.txt":     "text\n",
+		"lines.txt":      "1\n2\n3\n4\n5\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "docs", "bin.md"), []byte{'x', 0, 'y'}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	plugin := &FilePlugin{Config: &FileConfig{MaxSize: MaxFileSize, MaxTotalSize: DefaultMaxTotalFileSize}}
+	linesFile := filepath.Join(tmpDir, "lines.txt")
+
+	tests := []struct {
+		name        string
+		operation   string
+		value       string
+		want        string
+		errContains string
+	}{
+		{name: "head", operation: "head", value: linesFile + "|2", want: "1\n2"},
+		{name: "head longer than file", operation: "head", value: linesFile + "|10", want: "1\n2\n3\n4\n5"},
+		{name: "line range", operation: "lines", value: linesFile + "|2-4", want: "2\n3\n4"},
+		{name: "open line range", operation: "lines", value: linesFile + "|4-", want: "4\n5"},
+		{name: "invalid line range", operation: "lines", value: linesFile + "|4-2", errContains: "invalid line range"},
+		{
+			name:      "glob with headers",
+			operation: "glob",
+			value:     filepath.Join(tmpDir, "docs", "*.md"),
+			want:      "==> " + filepath.Join(tmpDir, "docs", "b.md") + " <==\n# B\n",
+		},
+		{
+			name:      "recursive glob",
+			operation: "glob",
+			value:     filepath.Join(tmpDir, "**", "*.md"),
+			want: "==> " + filepath.Join(tmpDir, "a.md") + " <==\n# A\n\n" +
+				"==> " + filepath.Join(tmpDir, "docs", "b.md") + " <==\n# B\n\n" +
+				"==> " + filepath.Join(tmpDir, "docs", "deep", "c.md") + " <==\n# C\n",
+		},
+		{
+			name:      "glob with a wildcard directory",
+			operation: "glob",
+			value:     filepath.Join(tmpDir, "*", "*.md"),
+			want:      "==> " + filepath.Join(tmpDir, "docs", "b.md") + " <==\n# B\n",
+		},
+		{name: "glob without matches", operation: "glob", value: filepath.Join(tmpDir, "*.go"), errContains: "no files match"},
+		{
+			name:      "tree",
+			operation: "tree",
+			value:     filepath.Join(tmpDir, "docs"),
+			want: filepath.Join(tmpDir, "docs") + "\n" +
+				"├── b.md\n├── bin.md\n├── d.txt\n└── deep/\n    └── c.md",
+		},
+		{
+			name:      "tree with depth",
+			operation: "tree",
+			value:     filepath.Join(tmpDir, "docs") + "|1",
+			want:      filepath.Join(tmpDir, "docs") + "\n├── b.md\n├── bin.md\n├── d.txt\n└── deep/",
+		},
+		{name: "tree of a file", operation: "tree", value: linesFile, errContains: "not a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plugin.Apply(tt.operation, tt.value)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FilePlugin.Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchGlobDir(t *testing.T) {
+	tests := []struct {
+		pattern string
+		dir     string
+		want    bool
+	}{
+		{pattern: "/tmp/*.log", dir: "/tmp", want: true},
+		{pattern: "/tmp/*.log", dir: "/tmp/sub", want: false},
+		{pattern: "/tmp/*/*.log", dir: "/tmp/sub", want: true},
+		{pattern: "/tmp/*/*.log", dir: "/tmp/sub/deeper", want: false},
+		{pattern: "/tmp/a*/*.log", dir: "/tmp/b", want: false},
+		{pattern: "/tmp/**/*.log", dir: "/tmp/sub/deeper", want: true},
+		{pattern: "*.log", dir: "sub", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := matchGlobDir(tt.pattern, tt.dir); got != tt.want {
+			t.Errorf("matchGlobDir(%q, %q) = %v, want %v", tt.pattern, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestFilePluginAllowedRoots(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "ok.txt"), []byte("ok"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(root, "link.txt")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	plugin := &FilePlugin{Config: &FileConfig{
+		AllowedRoots: []string{root},
+		MaxSize:      MaxFileSize,
+		MaxTotalSize: DefaultMaxTotalFileSize,
+	}}
+
+	if got, err := plugin.Apply("read", filepath.Join(root, "ok.txt")); err != nil || got != "ok" {
+		t.Errorf("read inside root = %q, %v", got, err)
+	}
+	if got, err := plugin.Apply("exists", filepath.Join(root, "missing.txt")); err != nil || got != "false" {
+		t.Errorf("exists inside root = %q, %v", got, err)
+	}
+	for _, path := range []string{secret, filepath.Join(root, "link.txt")} {
+		if _, err := plugin.Apply("read", path); err == nil || !strings.Contains(err.Error(), "outside of") {
+			t.Errorf("read %s: expected error outside of roots, got %v", path, err)
+		}
+	}
+
+	// glob leaves out the symlink leaving the root and does not walk
+	// directories outside of it
+	want := fmt.Sprintf(fileGlobHeaderFormat, filepath.Join(root, "ok.txt")) + "ok\n"
+	if got, err := plugin.Apply("glob", filepath.Join(root, "*.txt")); err != nil || got != want {
+		t.Errorf("glob inside root = %q, %v, want %q", got, err, want)
+	}
+	parentPattern := filepath.Join(filepath.Dir(root), "**", "*.txt")
+	if got, err := plugin.Apply("glob", parentPattern); err != nil || got != want {
+		t.Errorf("glob from parent of root = %q, %v, want %q", got, err, want)
+	}
+	if _, err := plugin.Apply("glob", filepath.Join(outside, "*.txt")); err == nil || !strings.Contains(err.Error(), "outside of") {
+		t.Errorf("glob outside of roots: expected error, got %v", err)
+	}
+}
+
+func TestFilePluginTemplateBudget(t *testing.T) {
+	t.Setenv(FileMaxTotalSizeEnv, "10")
+	path := filepath.Join(t.TempDir(), "six.txt")
+	if err := os.WriteFile(path, []byte("123456"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	read := "{{plugin:file:read:" + path + "}}"
+	if got, err := ApplyTemplate(read, nil, ""); err != nil || got != "123456" {
+		t.Fatalf("single read = %q, %v", got, err)
+	}
+	// the budget is per template, so a second template starts over
+	if _, err := ApplyTemplate(read, nil, ""); err != nil {
+		t.Fatalf("second template: %v", err)
+	}
+	if _, err := ApplyTemplate(read+read, nil, ""); err == nil || !strings.Contains(err.Error(), "budget") {
+		t.Errorf("expected budget error, got %v", err)
+	}
+}
@@ -0,0 +1,510 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// DefaultGitLogCount is the number of commits returned by log without a count
+const DefaultGitLogCount = 10
+
+// GitPlugin reads from the git repository containing Dir (the current
+// directory when empty). Everything runs in-process through go-git.
+type GitPlugin struct {
+	Dir string
+}
+
+// Name returns the template namespace of the plugin
+func (p *GitPlugin) Name() string {
+	return "git"
+}
+
+// Description returns a short description of the plugin
+func (p *GitPlugin) Description() string {
+	return "Git repository information of the current directory"
+}
+
+// Operations lists the supported git operations
+func (p *GitPlugin) Operations() []Operation {
+	return []Operation{
+		{Name: "diff", Usage: pluginUsage("git", "diff", ""), Description: "Unstaged changes of the working tree"},
+		{Name: "diff", Usage: pluginUsage("git", "diff", "staged"), Description: "Staged changes"},
+		{Name: "diff", Usage: pluginUsage("git", "diff", "REF1..REF2"), Description: "Changes between two refs (REF alone compares with HEAD)"},
+		{Name: "log", Usage: pluginUsage("git", "log", "N"), Description: "Last N commits of HEAD (default 10)"},
+		{Name: "log", Usage: pluginUsage("git", "log", "REF1..REF2"), Description: "Commits in REF2 that are not in REF1"},
+		{Name: "show", Usage: pluginUsage("git", "show", "REF"), Description: "Commit message and patch (default HEAD)"},
+		{Name: "branch", Usage: pluginUsage("git", "branch", ""), Description: "Current branch name"},
+		{Name: "files", Usage: pluginUsage("git", "files", "SPEC"), Description: "Changed files with status, SPEC as for diff"},
+	}
+}
+
+// Apply executes git operations:
+//   - diff[:staged|REF|REF1..REF2]: unified diff
+//   - log[:N|REF|REF1..REF2]: commit log
+//   - show[:REF]: commit with its patch
+//   - branch: current branch
+//   - files[:staged|REF|REF1..REF2]: changed files
+func (p *GitPlugin) Apply(operation string, value string) (string, error) {
+	debugf("Git: operation=%q value=%q", operation, value)
+
+	dir := p.Dir
+	if dir == "" {
+		dir = "."
+	}
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", fmt.Errorf("git: could not open repository: %v", err)
+	}
+
+	switch operation {
+	case "diff":
+		return p.diff(repo, value)
+	case "log":
+		return p.log(repo, value)
+	case "show":
+		return p.show(repo, value)
+	case "branch":
+		return p.branch(repo)
+	case "files":
+		return p.files(repo, value)
+	default:
+		return "", fmt.Errorf("git: unknown operation %q (supported: diff, log, show, branch, files)", operation)
+	}
+}
+
+func (p *GitPlugin) diff(repo *git.Repository, spec string) (string, error) {
+	filePatches, err := p.filePatches(repo, spec)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err = fdiff.NewUnifiedEncoder(&buf, fdiff.DefaultContextLines).Encode(&gitPatch{filePatches: filePatches}); err != nil {
+		return "", fmt.Errorf("git: could not encode diff: %v", err)
+	}
+	return buf.String(), nil
+}
+
+func (p *GitPlugin) files(repo *git.Repository, spec string) (string, error) {
+	filePatches, err := p.filePatches(repo, spec)
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, fp := range filePatches {
+		from, to := fp.Files()
+		switch {
+		case from == nil:
+			lines = append(lines, "A "+to.Path())
+		case to == nil:
+			lines = append(lines, "D "+from.Path())
+		case from.Path() != to.Path():
+			lines = append(lines, fmt.Sprintf("R %s -> %s", from.Path(), to.Path()))
+		default:
+			lines = append(lines, "M "+to.Path())
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// filePatches computes the per-file changes for a diff spec
+func (p *GitPlugin) filePatches(repo *git.Repository, spec string) ([]fdiff.FilePatch, error) {
+	switch spec {
+	case "", "working":
+		return p.worktreePatches(repo, false)
+	case "staged", "cached":
+		return p.worktreePatches(repo, true)
+	}
+
+	fromRef, toRef := spec, "HEAD"
+	if i := strings.Index(spec, ".."); i >= 0 {
+		fromRef, toRef = spec[:i], spec[i+2:]
+	}
+	from, err := p.commit(repo, fromRef)
+	if err != nil {
+		return nil, err
+	}
+	to, err := p.commit(repo, toRef)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := p.commitPatch(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return patch.FilePatches(), nil
+}
+
+// commitPatch diffs two commits; from may be nil for the root commit
+func (p *GitPlugin) commitPatch(from, to *object.Commit) (*object.Patch, error) {
+	fromTree := &object.Tree{}
+	if from != nil {
+		var err error
+		if fromTree, err = from.Tree(); err != nil {
+			return nil, fmt.Errorf("git: could not read tree: %v", err)
+		}
+	}
+	toTree, err := to.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("git: could not read tree: %v", err)
+	}
+
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, fmt.Errorf("git: could not diff trees: %v", err)
+	}
+	patch, err := changes.Patch()
+	if err != nil {
+		return nil, fmt.Errorf("git: could not create patch: %v", err)
+	}
+	return patch, nil
+}
+
+// worktreePatches diffs HEAD against the index (staged) or the index against
+// the files in the working tree (unstaged). Untracked files are ignored.
+func (p *GitPlugin) worktreePatches(repo *git.Repository, staged bool) (ret []fdiff.FilePatch, err error) {
+	var wt *git.Worktree
+	if wt, err = repo.Worktree(); err != nil {
+		return nil, fmt.Errorf("git: could not open worktree: %v", err)
+	}
+	var status git.Status
+	if status, err = wt.Status(); err != nil {
+		return nil, fmt.Errorf("git: could not get status: %v", err)
+	}
+
+	paths := make([]string, 0, len(status))
+	for path := range status {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		fileStatus := status[path]
+		code := fileStatus.Worktree
+		if staged {
+			code = fileStatus.Staging
+		}
+		if code == git.Unmodified || code == git.Untracked {
+			continue
+		}
+
+		var from, to *fileVersion
+		if staged {
+			if from, err = p.headVersion(repo, path); err != nil {
+				return
+			}
+			if to, err = p.indexVersion(repo, path); err != nil {
+				return
+			}
+		} else {
+			if from, err = p.indexVersion(repo, path); err != nil {
+				return
+			}
+			if to, err = p.worktreeVersion(wt, path); err != nil {
+				return
+			}
+		}
+		if from == nil && to == nil {
+			continue
+		}
+		ret = append(ret, newGitFilePatch(from, to))
+	}
+	return
+}
+
+func (p *GitPlugin) log(repo *git.Repository, value string) (string, error) {
+	count := DefaultGitLogCount
+	fromRef, toRef := "", "HEAD"
+	switch {
+	case value == "":
+	case strings.Contains(value, ".."):
+		i := strings.Index(value, "..")
+		fromRef, toRef = value[:i], value[i+2:]
+		count = 0
+	default:
+		if n, err := strconv.Atoi(value); err == nil {
+			if n <= 0 {
+				return "", fmt.Errorf("git: log count must be positive, got %d", n)
+			}
+			count = n
+		} else {
+			toRef = value
+		}
+	}
+
+	to, err := p.commit(repo, toRef)
+	if err != nil {
+		return "", err
+	}
+
+	exclude := make(map[plumbing.Hash]bool)
+	if fromRef != "" {
+		from, err := p.commit(repo, fromRef)
+		if err != nil {
+			return "", err
+		}
+		if err = p.walk(repo, from.Hash, func(c *object.Commit) error {
+			exclude[c.Hash] = true
+			return nil
+		}); err != nil {
+			return "", err
+		}
+	}
+
+	var commits []string
+	err = p.walk(repo, to.Hash, func(c *object.Commit) error {
+		if exclude[c.Hash] {
+			return nil
+		}
+		commits = append(commits, c.String())
+		if count > 0 && len(commits) >= count {
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(commits, "\n"), nil
+}
+
+var errStopWalk = errors.New("stop walk")
+
+// walk visits the commits reachable from hash, newest first
+func (p *GitPlugin) walk(repo *git.Repository, hash plumbing.Hash, fn func(*object.Commit) error) error {
+	iter, err := repo.Log(&git.LogOptions{From: hash})
+	if err != nil {
+		return fmt.Errorf("git: could not read log: %v", err)
+	}
+	defer iter.Close()
+
+	if err = iter.ForEach(fn); err != nil && err != errStopWalk {
+		return fmt.Errorf("git: could not read log: %v", err)
+	}
+	return nil
+}
+
+func (p *GitPlugin) show(repo *git.Repository, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	commit, err := p.commit(repo, ref)
+	if err != nil {
+		return "", err
+	}
+
+	var parent *object.Commit
+	if commit.NumParents() > 0 {
+		if parent, err = commit.Parent(0); err != nil {
+			return "", fmt.Errorf("git: could not read parent of %s: %v", ref, err)
+		}
+	}
+	patch, err := p.commitPatch(parent, commit)
+	if err != nil {
+		return "", err
+	}
+	return commit.String() + "\n" + patch.String(), nil
+}
+
+func (p *GitPlugin) branch(repo *git.Repository) (string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("git: could not read HEAD: %v", err)
+	}
+	if head.Name().IsBranch() {
+		return head.Name().Short(), nil
+	}
+	return head.Hash().String(), nil
+}
+
+// commit resolves a revision such as HEAD~2, a branch, a tag or a hash
+func (p *GitPlugin) commit(repo *git.Repository, ref string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("git: could not resolve %q: %v", ref, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("git: could not read commit %q: %v", ref, err)
+	}
+	return commit, nil
+}
+
+// fileVersion is the content of a file in HEAD, the index or the worktree
+type fileVersion struct {
+	path    string
+	mode    filemode.FileMode
+	content []byte
+}
+
+func (p *GitPlugin) headVersion(repo *git.Repository, path string) (*fileVersion, error) {
+	head, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return nil, nil // no commits yet
+	} else if err != nil {
+		return nil, fmt.Errorf("git: could not read HEAD: %v", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("git: could not read HEAD commit: %v", err)
+	}
+	file, err := commit.File(path)
+	if err == object.ErrFileNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("git: could not read %s from HEAD: %v", path, err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("git: could not read %s from HEAD: %v", path, err)
+	}
+	return &fileVersion{path: path, mode: file.Mode, content: []byte(content)}, nil
+}
+
+func (p *GitPlugin) indexVersion(repo *git.Repository, path string) (*fileVersion, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("git: could not read index: %v", err)
+	}
+	entry, err := idx.Entry(path)
+	if err != nil {
+		return nil, nil // not in the index
+	}
+	blob, err := repo.BlobObject(entry.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("git: could not read %s from index: %v", path, err)
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("git: could not read %s from index: %v", path, err)
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("git: could not read %s from index: %v", path, err)
+	}
+	return &fileVersion{path: path, mode: entry.Mode, content: content}, nil
+}
+
+func (p *GitPlugin) worktreeVersion(wt *git.Worktree, path string) (*fileVersion, error) {
+	file, err := wt.Filesystem.Open(path)
+	if err != nil {
+		return nil, nil // deleted from the working tree
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("git: could not read %s: %v", path, err)
+	}
+	mode := filemode.Regular
+	if info, statErr := wt.Filesystem.Lstat(path); statErr == nil {
+		if m, modeErr := filemode.NewFromOSFileMode(info.Mode()); modeErr == nil {
+			mode = m
+		}
+	}
+	return &fileVersion{path: path, mode: mode, content: content}, nil
+}
+
+// gitPatch implements the go-git diff.Patch interface for worktree diffs
+type gitPatch struct {
+	filePatches []fdiff.FilePatch
+}
+
+func (o *gitPatch) FilePatches() []fdiff.FilePatch { return o.filePatches }
+func (o *gitPatch) Message() string                { return "" }
+
+type gitFile struct {
+	path string
+	mode filemode.FileMode
+	hash plumbing.Hash
+}
+
+func (o *gitFile) Hash() plumbing.Hash     { return o.hash }
+func (o *gitFile) Mode() filemode.FileMode { return o.mode }
+func (o *gitFile) Path() string            { return o.path }
+
+type gitChunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (o *gitChunk) Content() string       { return o.content }
+func (o *gitChunk) Type() fdiff.Operation { return o.op }
+
+type gitFilePatch struct {
+	from, to *gitFile
+	binary   bool
+	chunks   []fdiff.Chunk
+}
+
+func newGitFilePatch(from, to *fileVersion) *gitFilePatch {
+	ret := &gitFilePatch{from: from.file(), to: to.file()}
+
+	var fromContent, toContent []byte
+	if from != nil {
+		fromContent = from.content
+	}
+	if to != nil {
+		toContent = to.content
+	}
+	if isBinary(fromContent) || isBinary(toContent) {
+		ret.binary = true
+		return ret
+	}
+
+	for _, d := range diff.Do(string(fromContent), string(toContent)) {
+		op := fdiff.Equal
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = fdiff.Delete
+		case diffmatchpatch.DiffInsert:
+			op = fdiff.Add
+		}
+		ret.chunks = append(ret.chunks, &gitChunk{content: d.Text, op: op})
+	}
+	return ret
+}
+
+func (o *fileVersion) file() *gitFile {
+	if o == nil {
+		return nil
+	}
+	return &gitFile{path: o.path, mode: o.mode, hash: plumbing.ComputeHash(plumbing.BlobObject, o.content)}
+}
+
+func (o *gitFilePatch) IsBinary() bool        { return o.binary }
+func (o *gitFilePatch) Chunks() []fdiff.Chunk { return o.chunks }
+
+func (o *gitFilePatch) Files() (from, to fdiff.File) {
+	// avoid typed nil interfaces for added and deleted files
+	if o.from != nil {
+		from = o.from
+	}
+	if o.to != nil {
+		to = o.to
+	}
+	return
+}
+
+// isBinary uses git's heuristic of a NUL byte in the first 8000 bytes
+func isBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
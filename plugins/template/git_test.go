@@ -0,0 +1,176 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// newTestRepo creates a repository with two commits on master
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit := func(file, content, message string) {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add(file); err != nil {
+			t.Fatal(err)
+		}
+		_, err := wt.Commit(message, &git.CommitOptions{
+			Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit("a.txt", "one\ntwo\n", "first commit")
+	commit("a.txt", "one\ntwo\nthree\n", "second commit")
+	return dir
+}
+
+func TestGitPlugin(t *testing.T) {
+	dir := newTestRepo(t)
+	plugin := &GitPlugin{Dir: dir}
+
+	// unstaged change to a.txt, staged new file b.txt
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\nthree\nfour\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo, _ := git.PlainOpen(dir)
+	wt, _ := repo.Worktree()
+	if _, err := wt.Add("b.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		operation string
+		value     string
+		contains  []string
+		excludes  []string
+		wantErr   bool
+	}{
+		{
+			name:      "branch",
+			operation: "branch",
+			contains:  []string{"master"},
+		},
+		{
+			name:      "working tree diff",
+			operation: "diff",
+			contains:  []string{"diff --git a/a.txt b/a.txt", "+four"},
+			excludes:  []string{"b.txt"},
+		},
+		{
+			name:      "staged diff",
+			operation: "diff",
+			value:     "staged",
+			contains:  []string{"new file mode", "+new"},
+			excludes:  []string{"a.txt"},
+		},
+		{
+			name:      "diff between refs",
+			operation: "diff",
+			value:     "HEAD~1..HEAD",
+			contains:  []string{"+three"},
+			excludes:  []string{"+four"},
+		},
+		{
+			name:      "changed files",
+			operation: "files",
+			contains:  []string{"M a.txt"},
+		},
+		{
+			name:      "staged files",
+			operation: "files",
+			value:     "staged",
+			contains:  []string{"A b.txt"},
+		},
+		{
+			name:      "log",
+			operation: "log",
+			contains:  []string{"second commit", "first commit"},
+		},
+		{
+			name:      "log count",
+			operation: "log",
+			value:     "1",
+			contains:  []string{"second commit"},
+			excludes:  []string{"first commit"},
+		},
+		{
+			name:      "log range",
+			operation: "log",
+			value:     "HEAD~1..HEAD",
+			contains:  []string{"second commit"},
+			excludes:  []string{"first commit"},
+		},
+		{
+			name:      "show root commit",
+			operation: "show",
+			value:     "HEAD~1",
+			contains:  []string{"first commit", "+one"},
+		},
+		{
+			name:      "invalid count",
+			operation: "log",
+			value:     "0",
+			wantErr:   true,
+		},
+		{
+			name:      "unknown ref",
+			operation: "show",
+			value:     "no-such-ref",
+			wantErr:   true,
+		},
+		{
+			name:      "unknown operation",
+			operation: "blame",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plugin.Apply(tt.operation, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GitPlugin.Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("output should contain %q, got:\n%s", want, got)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("output should not contain %q, got:\n%s", unwanted, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGitPluginOutsideRepository(t *testing.T) {
+	plugin := &GitPlugin{Dir: t.TempDir()}
+	if _, err := plugin.Apply("branch", ""); err == nil {
+		t.Error("expected error outside of a repository")
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 )
 
 // ComputeHash computes SHA-256 hash of a file at given path.
@@ -31,3 +33,45 @@ func ComputeStringHash(s string) string {
 	h.Write([]byte(s))
 	return hex.EncodeToString(h.Sum(nil))
 }
+
+// ComputeFileHashes returns the hex-encoded SHA-256 hashes of all files
+// below dir by their slash-separated relative path, symlinks are hashed by
+// their target
+func ComputeFileHashes(dir string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		hash, err := computeEntryHash(path)
+		if err != nil {
+			return err
+		}
+		hashes[filepath.ToSlash(rel)] = hash
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hash directory: %w", err)
+	}
+	return hashes, nil
+}
+
+// computeEntryHash hashes a file, or the target of a symlink
+func computeEntryHash(path string) (string, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		return ComputeStringHash("symlink:" + target), nil
+	}
+	return ComputeHash(path)
+}
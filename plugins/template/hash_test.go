@@ -117,3 +117,34 @@ func TestHashConsistency(t *testing.T) {
 		t.Errorf("Hash inconsistency: file hash %v != string hash %v", fileHash, stringHash)
 	}
 }
+
+func TestComputeFileHashes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "top.txt"), []byte("test content for hashing"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hashes, err := ComputeFileHashes(dir)
+	if err != nil {
+		t.Fatalf("ComputeFileHashes failed: %v", err)
+	}
+	if len(hashes) != 2 {
+		t.Errorf("got %d hashes, want 2: %v", len(hashes), hashes)
+	}
+	if got := hashes["top.txt"]; got != "e25dd806d495b413931f4eea50b677a7a5c02d00460924661283f211a37f7e7f" {
+		t.Errorf("hash of top.txt = %v", got)
+	}
+	if got, want := hashes["sub/a.txt"], ComputeStringHash("a"); got != want {
+		t.Errorf("hash of sub/a.txt = %v, want %v", got, want)
+	}
+
+	if _, err := ComputeFileHashes(filepath.Join(dir, "missing")); err == nil {
+		t.Error("ComputeFileHashes should fail for a missing directory")
+	}
+}
@@ -0,0 +1,220 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v3"
+)
+
+// JSONPlugin queries JSON documents with gjson path expressions
+// (https://github.com/tidwall/gjson/blob/master/SYNTAX.md)
+type JSONPlugin struct{}
+
+// Name returns the template namespace of the plugin
+func (p *JSONPlugin) Name() string {
+	return "json"
+}
+
+// Description returns a short description of the plugin
+func (p *JSONPlugin) Description() string {
+	return "Query JSON with path expressions and convert it to YAML"
+}
+
+// Operations lists the supported json operations
+func (p *JSONPlugin) Operations() []Operation {
+	return []Operation{
+		{Name: "get", Usage: pluginUsage("json", "get", "PATH:DATA"), Description: "Value at PATH, e.g. items.0.name or items.#.name"},
+		{Name: "to-yaml", Usage: pluginUsage("json", "to-yaml", "DATA"), Description: "Convert JSON to YAML"},
+	}
+}
+
+// Apply executes json operations:
+//   - get:PATH:DATA returns strings unquoted and objects or arrays as JSON
+//   - to-yaml:DATA converts the document to YAML, keeping the key order
+func (p *JSONPlugin) Apply(operation string, value string) (string, error) {
+	debugf("JSON: operation=%q", operation)
+
+	switch operation {
+	case "get":
+		path, data, ok := splitArgument(value)
+		if !ok || path == "" {
+			return "", fmt.Errorf("json: get requires PATH:DATA")
+		}
+		return jsonGet(path, data, "json")
+
+	case "to-yaml":
+		if !json.Valid([]byte(value)) {
+			return "", fmt.Errorf("json: invalid JSON")
+		}
+		// JSON is valid YAML, decoding it into a node keeps the key order
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(value), &doc); err != nil {
+			return "", fmt.Errorf("json: %v", err)
+		}
+		return marshalYAML(&doc, "json")
+
+	default:
+		return "", fmt.Errorf("json: unknown operation %q (supported: get, to-yaml)", operation)
+	}
+}
+
+// YAMLPlugin queries YAML documents with the path syntax of the json plugin
+type YAMLPlugin struct{}
+
+// Name returns the template namespace of the plugin
+func (p *YAMLPlugin) Name() string {
+	return "yaml"
+}
+
+// Description returns a short description of the plugin
+func (p *YAMLPlugin) Description() string {
+	return "Query YAML with path expressions and convert it to JSON"
+}
+
+// Operations lists the supported yaml operations
+func (p *YAMLPlugin) Operations() []Operation {
+	return []Operation{
+		{Name: "get", Usage: pluginUsage("yaml", "get", "PATH:DATA"), Description: "Value at PATH, same syntax as json:get"},
+		{Name: "to-json", Usage: pluginUsage("yaml", "to-json", "DATA"), Description: "Convert YAML to JSON"},
+	}
+}
+
+// Apply executes yaml operations:
+//   - get:PATH:DATA
+//   - to-json:DATA
+func (p *YAMLPlugin) Apply(operation string, value string) (string, error) {
+	debugf("YAML: operation=%q", operation)
+
+	switch operation {
+	case "get":
+		path, data, ok := splitArgument(value)
+		if !ok || path == "" {
+			return "", fmt.Errorf("yaml: get requires PATH:DATA")
+		}
+		converted, err := yamlToJSON(data)
+		if err != nil {
+			return "", err
+		}
+		return jsonGet(path, converted, "yaml")
+
+	case "to-json":
+		converted, err := yamlToJSON(value)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err = json.Indent(&buf, []byte(converted), "", "  "); err != nil {
+			return "", fmt.Errorf("yaml: %v", err)
+		}
+		return buf.String(), nil
+
+	default:
+		return "", fmt.Errorf("yaml: unknown operation %q (supported: get, to-json)", operation)
+	}
+}
+
+// jsonGet evaluates a gjson path; namespace prefixes error messages
+func jsonGet(path, data, namespace string) (string, error) {
+	if !gjson.Valid(data) {
+		return "", fmt.Errorf("%s: invalid JSON", namespace)
+	}
+	result := gjson.Get(data, path)
+	if !result.Exists() {
+		return "", fmt.Errorf("%s: path %q not found", namespace, path)
+	}
+	if result.Type == gjson.JSON {
+		return result.Raw, nil
+	}
+	return result.String(), nil
+}
+
+// marshalYAML encodes a node in block style with two space indentation
+func marshalYAML(node *yaml.Node, namespace string) (string, error) {
+	resetYAMLStyle(node)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return "", fmt.Errorf("%s: %v", namespace, err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("%s: %v", namespace, err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// resetYAMLStyle drops the flow and quoting style of decoded JSON
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// yamlToJSON converts a YAML document to compact JSON, keeping the key order
+func yamlToJSON(data string) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(data), &doc); err != nil {
+		return "", fmt.Errorf("yaml: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := writeYAMLNodeJSON(&buf, &doc); err != nil {
+		return "", fmt.Errorf("yaml: %v", err)
+	}
+	return buf.String(), nil
+}
+
+func writeYAMLNodeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case 0:
+		buf.WriteString("null") // empty document
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeYAMLNodeJSON(buf, node.Content[0])
+	case yaml.AliasNode:
+		return writeYAMLNodeJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(node.Content[i].Value)
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeYAMLNodeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, child := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeYAMLNodeJSON(buf, child); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package template
+
+import "testing"
+
+func TestJSONPlugin(t *testing.T) {
+	plugin := &JSONPlugin{}
+	data := `{"name":"fabric","tags":["ai","cli"],"items":[{"id":1,"url":"https://a"},{"id":2,"url":"https://b"}]}`
+
+	tests := []struct {
+		name      string
+		operation string
+		value     string
+		want      string
+		wantErr   bool
+	}{
+		{name: "string unquoted", operation: "get", value: "name:" + data, want: "fabric"},
+		{name: "array element", operation: "get", value: "tags.1:" + data, want: "cli"},
+		{name: "nested number", operation: "get", value: "items.1.id:" + data, want: "2"},
+		{name: "array stays json", operation: "get", value: "items.#.id:" + data, want: "[1,2]"},
+		{name: "value containing colons", operation: "get", value: "items.0.url:" + data, want: "https://a"},
+		{name: "missing path", operation: "get", value: "nope:" + data, wantErr: true},
+		{name: "invalid json", operation: "get", value: "name:{oops", wantErr: true},
+		{name: "missing path argument", operation: "get", value: data, wantErr: true},
+		{
+			name:      "to yaml keeps key order",
+			operation: "to-yaml",
+			value:     `{"b":1,"a":{"list":["x","y"]},"s":"two words"}`,
+			want:      "b: 1\na:\n  list:\n    - x\n    - y\ns: two words",
+		},
+		{name: "to yaml invalid", operation: "to-yaml", value: "{", wantErr: true},
+		{name: "unknown operation", operation: "set", value: data, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plugin.Apply(tt.operation, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("JSONPlugin.Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("JSONPlugin.Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLPlugin(t *testing.T) {
+	plugin := &YAMLPlugin{}
+	data := "name: fabric\nversion: 1.4\nitems:\n  - id: 1\n    enabled: true\n  - id: 2\n    enabled: false\n"
+
+	tests := []struct {
+		name      string
+		operation string
+		value     string
+		want      string
+		wantErr   bool
+	}{
+		{name: "get scalar", operation: "get", value: "name:" + data, want: "fabric"},
+		{name: "get nested", operation: "get", value: "items.1.enabled:" + data, want: "false"},
+		{name: "get query", operation: "get", value: "items.#(id==2).id:" + data, want: "2"},
+		{
+			name:      "to json keeps key order",
+			operation: "to-json",
+			value:     "b: 1\na: [x, y]\n",
+			want:      "{\n  \"b\": 1,\n  \"a\": [\n    \"x\",\n    \"y\"\n  ]\n}",
+		},
+		{name: "invalid yaml", operation: "to-json", value: "a: [", wantErr: true},
+		{name: "unknown operation", operation: "merge", value: data, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plugin.Apply(tt.operation, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("YAMLPlugin.Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("YAMLPlugin.Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPluginInTemplate(t *testing.T) {
+	got, err := ApplyTemplate("Title: {{plugin:json:get:title:{{doc}}}}",
+		map[string]string{"doc": `{"title":"a: b {{x}}"}`}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Title: a: b {{x}}"; got != want {
+		t.Errorf("ApplyTemplate() = %q, want %q", got, want)
+	}
+}
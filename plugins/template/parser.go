@@ -0,0 +1,295 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type nodeKind int
+
+const (
+	textNode nodeKind = iota // literal text
+	tagNode                  // {{...}} expression, possibly with nested tags
+	ifNode                   // {{#if name}}...{{else}}...{{/if}}
+	eachNode                 // {{#each name}}...{{else}}...{{/each}}
+)
+
+// node is an element of a parsed template
+type node struct {
+	kind     nodeKind
+	pos      int    // byte offset of the node in the template
+	text     string // literal text, or the variable name of a block
+	parts    []*node
+	body     []*node
+	elseBody []*node
+}
+
+// PositionError is a template error at a line and column of the template
+type PositionError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *PositionError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// newPositionError converts a byte offset in src into a line and column
+func newPositionError(src string, pos int, err error) error {
+	var posErr *PositionError
+	if errors.As(err, &posErr) {
+		return err
+	}
+	before := src[:pos]
+	line := strings.Count(before, "\n") + 1
+	lineStart := strings.LastIndex(before, "\n") + 1
+	return &PositionError{
+		Line:   line,
+		Column: utf8.RuneCountInString(before[lineStart:]) + 1,
+		Err:    err,
+	}
+}
+
+// terminator is a tag that ends a block body
+type terminator struct {
+	keyword string
+	pos     int
+}
+
+// errUnclosedTag reports a {{ without matching }}
+var errUnclosedTag = errors.New(`unclosed {{ (write \{{ for literal braces)`)
+
+// parser builds the node tree of a template in a single pass
+type parser struct {
+	src string
+	pos int
+}
+
+func parse(src string) (ret []*node, err error) {
+	p := &parser{src: src}
+	var term *terminator
+	if ret, term, err = p.parseNodes(); err != nil {
+		return
+	}
+	if term != nil {
+		err = p.errorAt(term.pos, fmt.Errorf("unexpected {{%s}} without matching block", term.keyword))
+	}
+	return
+}
+
+func (p *parser) errorAt(pos int, err error) error {
+	return newPositionError(p.src, pos, err)
+}
+
+// parseNodes reads text, tags and blocks until the end of the template or a
+// block terminator ({{else}} or a closing tag), which is returned
+func (p *parser) parseNodes() (ret []*node, term *terminator, err error) {
+	var text strings.Builder
+	textStart := p.pos
+	flush := func() {
+		if text.Len() > 0 {
+			ret = append(ret, &node{kind: textNode, pos: textStart, text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.src) {
+		if text.Len() == 0 {
+			textStart = p.pos
+		}
+		rest := p.src[p.pos:]
+		idx := strings.Index(rest, "{{")
+		if idx < 0 {
+			text.WriteString(rest)
+			p.pos = len(p.src)
+			break
+		}
+		if idx > 0 && rest[idx-1] == '\\' {
+			text.WriteString(rest[:idx-1])
+			text.WriteString("{{")
+			p.pos += idx + 2
+			continue
+		}
+		text.WriteString(rest[:idx])
+		p.pos += idx
+		flush()
+
+		tagStart := p.pos
+		var tag *node
+		if tag, err = p.parseTag(); err != nil {
+			// pasted text may hold a lone {{, it stays text unless it starts
+			// a block tag
+			if !errors.Is(err, errUnclosedTag) || strings.HasPrefix(strings.TrimSpace(p.src[tagStart+2:]), "#") {
+				return
+			}
+			err = nil
+			textStart = tagStart
+			text.WriteString("{{")
+			p.pos = tagStart + 2
+			continue
+		}
+
+		keyword, name := tag.keyword()
+		switch keyword {
+		case "":
+			ret = append(ret, tag)
+		case "#raw":
+			end := strings.Index(p.src[p.pos:], "{{/raw}}")
+			if end < 0 {
+				err = p.errorAt(tagStart, errors.New("unclosed {{#raw}}"))
+				return
+			}
+			ret = append(ret, &node{kind: textNode, pos: p.pos, text: p.src[p.pos : p.pos+end]})
+			p.pos += end + len("{{/raw}}")
+		case "#if", "#each":
+			var block *node
+			if block, err = p.parseBlock(keyword, name, tagStart); err != nil {
+				return
+			}
+			ret = append(ret, block)
+		default:
+			term = &terminator{keyword: keyword, pos: tagStart}
+			return
+		}
+	}
+	flush()
+	return
+}
+
+// parseBlock reads the body of a block up to its closing tag
+func (p *parser) parseBlock(keyword, name string, start int) (ret *node, err error) {
+	ret = &node{kind: ifNode, pos: start, text: name}
+	if keyword == "#each" {
+		ret.kind = eachNode
+	}
+
+	var term *terminator
+	if ret.body, term, err = p.parseNodes(); err != nil {
+		return
+	}
+	if term != nil && term.keyword == "else" {
+		if ret.elseBody, term, err = p.parseNodes(); err != nil {
+			return
+		}
+		if term != nil && term.keyword == "else" {
+			err = p.errorAt(term.pos, fmt.Errorf("duplicate {{else}} in {{%s %s}}", keyword, name))
+			return
+		}
+	}
+
+	switch {
+	case term == nil:
+		err = p.errorAt(start, fmt.Errorf("unclosed {{%s %s}}", keyword, name))
+	case term.keyword != "/"+strings.TrimPrefix(keyword, "#"):
+		err = p.errorAt(term.pos, fmt.Errorf("{{%s %s}} closed by {{%s}}", keyword, name, term.keyword))
+	}
+	return
+}
+
+// parseTag reads a {{...}} tag starting at the current position. Nested tags
+// become parts of the tag, a single brace is plain text.
+func (p *parser) parseTag() (ret *node, err error) {
+	ret = &node{kind: tagNode, pos: p.pos}
+	p.pos += 2
+
+	var text strings.Builder
+	textStart := p.pos
+	flush := func() {
+		if text.Len() > 0 {
+			ret.parts = append(ret.parts, &node{kind: textNode, pos: textStart, text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.src) {
+		if text.Len() == 0 {
+			textStart = p.pos
+		}
+		rest := p.src[p.pos:]
+		switch {
+		case strings.HasPrefix(rest, "}}"):
+			flush()
+			p.pos += 2
+			return
+		case strings.HasPrefix(rest, `\{{`):
+			text.WriteString("{{")
+			p.pos += 3
+		case strings.HasPrefix(rest, "{{"):
+			flush()
+			var nested *node
+			if nested, err = p.parseTag(); err != nil {
+				return
+			}
+			ret.parts = append(ret.parts, nested)
+		default:
+			text.WriteByte(rest[0])
+			p.pos++
+		}
+	}
+	return nil, p.errorAt(ret.pos, errUnclosedTag)
+}
+
+// keyword returns the block keyword and variable name of a static tag such
+// as {{#if name}} or {{/each}}, or an empty keyword for expression tags
+func (n *node) keyword() (keyword string, name string) {
+	if len(n.parts) != 1 || n.parts[0].kind != textNode {
+		return
+	}
+	fields := strings.Fields(n.parts[0].text)
+	switch {
+	case len(fields) == 1 && isTerminatorKeyword(fields[0]):
+		keyword = fields[0]
+	case len(fields) == 1 && fields[0] == "#raw":
+		keyword = fields[0]
+	case len(fields) == 2 && (fields[0] == "#if" || fields[0] == "#each"):
+		keyword, name = fields[0], fields[1]
+	}
+	return
+}
+
+func isTerminatorKeyword(s string) bool {
+	return s == "else" || s == "/if" || s == "/each" || s == "/raw"
+}
+
+// staticText returns the tag content when it has no nested tags
+func (n *node) staticText() (ret string, ok bool) {
+	switch len(n.parts) {
+	case 0:
+		return "", true
+	case 1:
+		if n.parts[0].kind == textNode {
+			return n.parts[0].text, true
+		}
+	}
+	return "", false
+}
+
+// walkVariables calls fn with the name of every plain variable tag
+func walkVariables(nodes []*node, fn func(name string)) {
+	for _, n := range nodes {
+		switch n.kind {
+		case tagNode:
+			walkVariables(n.parts, fn)
+			if text, ok := n.staticText(); ok && !isCall(text) {
+				if filters := splitFilters(text); len(filters) > 0 {
+					fn(filters[0])
+				}
+			}
+		case ifNode, eachNode:
+			walkVariables(n.body, fn)
+			walkVariables(n.elseBody, fn)
+		}
+	}
+}
+
+// isCall reports whether a tag expression is a plugin or extension call
+func isCall(expr string) bool {
+	return strings.HasPrefix(expr, pluginPrefix) || strings.HasPrefix(expr, extensionPrefix)
+}
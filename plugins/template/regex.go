@@ -0,0 +1,95 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RegexPlugin extracts matches and capture groups with Go regular expressions.
+// A colon inside the pattern is written as \:
+type RegexPlugin struct{}
+
+// Name returns the template namespace of the plugin
+func (p *RegexPlugin) Name() string {
+	return "regex"
+}
+
+// Description returns a short description of the plugin
+func (p *RegexPlugin) Description() string {
+	return "Extract matches and capture groups with regular expressions"
+}
+
+// Operations lists the supported regex operations
+func (p *RegexPlugin) Operations() []Operation {
+	return []Operation{
+		{Name: "match", Usage: pluginUsage("regex", "match", "PATTERN:DATA"), Description: "First match of PATTERN"},
+		{Name: "group", Usage: pluginUsage("regex", "group", "N:PATTERN:DATA"), Description: "Capture group N (number or name) of the first match"},
+		{Name: "groups", Usage: pluginUsage("regex", "groups", "PATTERN:DATA"), Description: "All capture groups of the first match, one per line"},
+		{Name: "all", Usage: pluginUsage("regex", "all", "PATTERN:DATA"), Description: "Every match, or its first capture group, one per line"},
+	}
+}
+
+// Apply executes regex operations. match, group and groups fail when nothing
+// matches, all returns an empty string.
+func (p *RegexPlugin) Apply(operation string, value string) (string, error) {
+	debugf("Regex: operation=%q", operation)
+
+	group := ""
+	if operation == "group" {
+		var ok bool
+		if group, value, ok = splitArgument(value); !ok || group == "" {
+			return "", fmt.Errorf("regex: group requires N:PATTERN:DATA")
+		}
+	}
+
+	switch operation {
+	case "match", "group", "groups", "all":
+	default:
+		return "", fmt.Errorf("regex: unknown operation %q (supported: match, group, groups, all)", operation)
+	}
+
+	pattern, data, ok := splitArgument(value)
+	if !ok || pattern == "" {
+		return "", fmt.Errorf("regex: %s requires PATTERN:DATA", operation)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("regex: invalid pattern: %v", err)
+	}
+
+	if operation == "all" {
+		var lines []string
+		for _, m := range re.FindAllStringSubmatch(data, -1) {
+			if len(m) > 1 {
+				lines = append(lines, m[1])
+			} else {
+				lines = append(lines, m[0])
+			}
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+
+	m := re.FindStringSubmatch(data)
+	if m == nil {
+		return "", fmt.Errorf("regex: no match for %q", pattern)
+	}
+
+	switch operation {
+	case "group":
+		index := re.SubexpIndex(group)
+		if index < 0 {
+			n, err := strconv.Atoi(group)
+			if err != nil || n < 0 || n >= len(m) {
+				return "", fmt.Errorf("regex: pattern %q has no group %q", pattern, group)
+			}
+			index = n
+		}
+		return m[index], nil
+	case "groups":
+		return strings.Join(m[1:], "\n"), nil
+	default:
+		return m[0], nil
+	}
+}
@@ -0,0 +1,42 @@
+package template
+
+import "testing"
+
+func TestRegexPlugin(t *testing.T) {
+	plugin := &RegexPlugin{}
+	data := "version: 1.4.2\nbuild 17 on 2024-11-20\nbuild 18 on 2024-11-21"
+
+	tests := []struct {
+		name      string
+		operation string
+		value     string
+		want      string
+		wantErr   bool
+	}{
+		{name: "first match", operation: "match", value: `build \d+:` + data, want: "build 17"},
+		{name: "escaped colon in pattern", operation: "match", value: `version\: [\d.]+:` + data, want: "version: 1.4.2"},
+		{name: "numbered group", operation: "group", value: `2:(\d+)-(\d+):` + data, want: "11"},
+		{name: "named group", operation: "group", value: `day:\d+-\d+-(?P<day>\d+):` + data, want: "20"},
+		{name: "unknown group", operation: "group", value: `3:(\d+):` + data, wantErr: true},
+		{name: "all groups", operation: "groups", value: `(\d+)\.(\d+)\.(\d+):` + data, want: "1\n4\n2"},
+		{name: "all matches use first group", operation: "all", value: `build (\d+):` + data, want: "17\n18"},
+		{name: "all matches without group", operation: "all", value: `\d{4}:` + data, want: "2024\n2024"},
+		{name: "all without match is empty", operation: "all", value: `nope:` + data, want: ""},
+		{name: "no match", operation: "match", value: `nope:` + data, wantErr: true},
+		{name: "invalid pattern", operation: "match", value: `(:` + data, wantErr: true},
+		{name: "missing data", operation: "match", value: `abc`, wantErr: true},
+		{name: "unknown operation", operation: "replace", value: `a:b`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plugin.Apply(tt.operation, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RegexPlugin.Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("RegexPlugin.Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
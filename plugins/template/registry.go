@@ -0,0 +1,151 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Operation documents a single operation of a template plugin
+type Operation struct {
+	Name        string
+	Usage       string
+	Description string
+}
+
+// Plugin is a template namespace, used as {{plugin:namespace:operation:value}}
+type Plugin interface {
+	Name() string
+	Description() string
+	Operations() []Operation
+	Apply(operation string, value string) (string, error)
+}
+
+// ScopedPlugin is implemented by plugins that keep state for the duration of
+// a single ApplyTemplate call, such as the byte budget of the file plugin
+type ScopedPlugin interface {
+	Plugin
+	// Scope returns the instance used for one template
+	Scope() Plugin
+}
+
+// PluginRegistry resolves template namespaces to plugins. Built-in plugins are
+// registered with Register, extensions come from the extension manager and
+// are addressed as {{ext:name:operation:value}}.
+type PluginRegistry struct {
+	plugins    map[string]Plugin
+	extensions *ExtensionManager
+}
+
+// NewPluginRegistry creates a registry; extensions may be nil
+func NewPluginRegistry(extensions *ExtensionManager) *PluginRegistry {
+	return &PluginRegistry{
+		plugins:    make(map[string]Plugin),
+		extensions: extensions,
+	}
+}
+
+// Register adds a plugin under its name
+func (r *PluginRegistry) Register(plugin Plugin) error {
+	name := plugin.Name()
+	if name == "" || strings.ContainsAny(name, ":{}| ") {
+		return fmt.Errorf("invalid template plugin name %q", name)
+	}
+	if _, exists := r.plugins[name]; exists {
+		return fmt.Errorf("template plugin %s is already registered", name)
+	}
+	r.plugins[name] = plugin
+	return nil
+}
+
+// Plugin returns the plugin registered for a namespace
+func (r *PluginRegistry) Plugin(namespace string) (Plugin, error) {
+	plugin, ok := r.plugins[namespace]
+	if !ok {
+		return nil, fmt.Errorf("unknown plugin namespace: %s", namespace)
+	}
+	return plugin, nil
+}
+
+// Extension returns the namespace of a registered extension
+func (r *PluginRegistry) Extension(name string) (Plugin, error) {
+	if r.extensions == nil {
+		return nil, fmt.Errorf("extension %s not found", name)
+	}
+	return r.extensions.Plugin(name)
+}
+
+// Plugins returns the built-in plugins sorted by name
+func (r *PluginRegistry) Plugins() (ret []Plugin) {
+	for _, plugin := range r.plugins {
+		ret = append(ret, plugin)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name() < ret[j].Name() })
+	return
+}
+
+// Print writes every namespace with its operations and documentation
+func (r *PluginRegistry) Print(w io.Writer) (err error) {
+	fmt.Fprintf(w, "Template plugins:\n\n")
+	for _, plugin := range r.Plugins() {
+		printPlugin(w, plugin)
+	}
+
+	if r.extensions == nil {
+		return
+	}
+	var extensions []Plugin
+	if extensions, err = r.extensions.Plugins(); err != nil {
+		return
+	}
+	if len(extensions) > 0 {
+		fmt.Fprintf(w, "Extensions:\n\n")
+		for _, plugin := range extensions {
+			printPlugin(w, plugin)
+		}
+	}
+	return
+}
+
+func printPlugin(w io.Writer, plugin Plugin) {
+	fmt.Fprintf(w, "%s - %s\n", plugin.Name(), plugin.Description())
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, op := range plugin.Operations() {
+		fmt.Fprintf(tw, "  %s\t%s\n", op.Usage, op.Description)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+}
+
+var defaultPluginRegistry = NewPluginRegistry(nil)
+
+func init() {
+	for _, plugin := range []Plugin{
+		textPlugin, datetimePlugin, filePlugin, fetchPlugin, sysPlugin, gitPlugin,
+		jsonPlugin, yamlPlugin, regexPlugin, csvPlugin,
+	} {
+		if err := defaultPluginRegistry.Register(plugin); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterPlugin adds a plugin to the registry used by ApplyTemplate
+func RegisterPlugin(plugin Plugin) error {
+	return defaultPluginRegistry.Register(plugin)
+}
+
+// PrintPlugins lists the namespaces available to ApplyTemplate
+func PrintPlugins(w io.Writer) error {
+	return defaultPluginRegistry.Print(w)
+}
+
+// pluginUsage builds the usage string of a plugin operation
+func pluginUsage(namespace, operation, value string) string {
+	if value == "" {
+		return fmt.Sprintf("{{plugin:%s:%s}}", namespace, operation)
+	}
+	return fmt.Sprintf("{{plugin:%s:%s:%s}}", namespace, operation, value)
+}
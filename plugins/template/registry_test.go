@@ -0,0 +1,87 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type echoPlugin struct{}
+
+func (p *echoPlugin) Name() string        { return "echotest" }
+func (p *echoPlugin) Description() string { return "Echo operations for tests" }
+func (p *echoPlugin) Operations() []Operation {
+	return []Operation{{Name: "echo", Usage: pluginUsage("echotest", "echo", "VALUE"), Description: "Return the value"}}
+}
+func (p *echoPlugin) Apply(operation string, value string) (string, error) {
+	if operation != "echo" {
+		return "", fmt.Errorf("echotest: unknown operation %q", operation)
+	}
+	return value, nil
+}
+
+func TestPluginRegistry(t *testing.T) {
+	registry := NewPluginRegistry(nil)
+
+	if err := registry.Register(&echoPlugin{}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := registry.Register(&echoPlugin{}); err == nil {
+		t.Error("Register() of a duplicate namespace should fail")
+	}
+
+	plugin, err := registry.Plugin("echotest")
+	if err != nil {
+		t.Fatalf("Plugin() error = %v", err)
+	}
+	if got, _ := plugin.Apply("echo", "hi"); got != "hi" {
+		t.Errorf("Apply() = %q, want %q", got, "hi")
+	}
+
+	if _, err := registry.Plugin("missing"); err == nil || !strings.Contains(err.Error(), "unknown plugin namespace") {
+		t.Errorf("Plugin() of unknown namespace error = %v", err)
+	}
+	if _, err := registry.Extension("missing"); err == nil {
+		t.Error("Extension() without extension manager should fail")
+	}
+
+	var out bytes.Buffer
+	if err := registry.Print(&out); err != nil {
+		t.Fatalf("Print() error = %v", err)
+	}
+	for _, want := range []string{"echotest - Echo operations for tests", "{{plugin:echotest:echo:VALUE}}", "Return the value"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Print() output %q should contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestDefaultRegistryBuiltins(t *testing.T) {
+	for _, name := range []string{"text", "datetime", "file", "fetch", "sys"} {
+		plugin, err := defaultPluginRegistry.Plugin(name)
+		if err != nil {
+			t.Errorf("built-in plugin %s not registered: %v", name, err)
+			continue
+		}
+		if len(plugin.Operations()) == 0 {
+			t.Errorf("plugin %s documents no operations", name)
+		}
+	}
+}
+
+func TestRegisterPluginUsedByTemplate(t *testing.T) {
+	if _, err := defaultPluginRegistry.Plugin("echotest"); err != nil {
+		if err := RegisterPlugin(&echoPlugin{}); err != nil {
+			t.Fatalf("RegisterPlugin() error = %v", err)
+		}
+	}
+
+	got, err := ApplyTemplate("{{plugin:echotest:echo:hello}}", nil, "")
+	if err != nil {
+		t.Fatalf("ApplyTemplate() error = %v", err)
+	}
+	if got != "hello" {
+		t.Errorf("ApplyTemplate() = %q, want %q", got, "hello")
+	}
+}
@@ -13,6 +13,29 @@ import (
 // environment variables. Be cautious with exposed variables in templates.
 type SysPlugin struct{}
 
+// Name returns the template namespace of the plugin
+func (p *SysPlugin) Name() string {
+	return "sys"
+}
+
+// Description returns a short description of the plugin
+func (p *SysPlugin) Description() string {
+	return "System information"
+}
+
+// Operations lists the supported system operations
+func (p *SysPlugin) Operations() []Operation {
+	return []Operation{
+		{Name: "hostname", Usage: pluginUsage("sys", "hostname", ""), Description: "System hostname"},
+		{Name: "user", Usage: pluginUsage("sys", "user", ""), Description: "Current username"},
+		{Name: "os", Usage: pluginUsage("sys", "os", ""), Description: "Operating system"},
+		{Name: "arch", Usage: pluginUsage("sys", "arch", ""), Description: "System architecture"},
+		{Name: "env", Usage: pluginUsage("sys", "env", "NAME"), Description: "Value of an environment variable"},
+		{Name: "pwd", Usage: pluginUsage("sys", "pwd", ""), Description: "Current working directory"},
+		{Name: "home", Usage: pluginUsage("sys", "home", ""), Description: "User home directory"},
+	}
+}
+
 // Apply executes system operations with the following options:
 //   - hostname: System hostname
 //   - user: Current username
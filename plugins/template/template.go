@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -14,6 +14,11 @@ var (
 	filePlugin     = &FilePlugin{}
 	fetchPlugin    = &FetchPlugin{}
 	sysPlugin      = &SysPlugin{}
+	gitPlugin      = &GitPlugin{}
+	jsonPlugin     = &JSONPlugin{}
+	yamlPlugin     = &YAMLPlugin{}
+	regexPlugin    = &RegexPlugin{}
+	csvPlugin      = &CSVPlugin{}
 	Debug          = false // Debug flag
 )
 
@@ -26,11 +31,14 @@ func init() {
 	}
 	configDir := filepath.Join(homedir, ".config/fabric")
 	extensionManager = NewExtensionManager(configDir)
+	defaultPluginRegistry.extensions = extensionManager
 	// Extensions will work if registry exists, otherwise they'll just fail gracefully
 }
 
-var pluginPattern = regexp.MustCompile(`\{\{plugin:([^:]+):([^:]+)(?::([^}]+))?\}\}`)
-var extensionPattern = regexp.MustCompile(`\{\{ext:([^:]+):([^:]+)(?::([^}]+))?\}\}`)
+const (
+	pluginPrefix    = "plugin:"
+	extensionPrefix = "ext:"
+)
 
 func debugf(format string, a ...interface{}) {
 	if Debug {
@@ -38,208 +46,251 @@ func debugf(format string, a ...interface{}) {
 	}
 }
 
+// ApplyTemplate renders content in a single pass. Every placeholder is
+// evaluated once: the values of variables, {{input}} and the output of
+// plugins and extensions are inserted as plain text and never interpreted
+// as template syntax again.
 func ApplyTemplate(content string, variables map[string]string, input string) (string, error) {
-
-	var missingVars []string
-	r := regexp.MustCompile(`\{\{([^{}]+)\}\}`)
-
 	debugf("Starting template processing\n")
-	for strings.Contains(content, "{{") {
-		matches := r.FindAllStringSubmatch(content, -1)
-		if len(matches) == 0 {
-			break
-		}
+	nodes, err := parse(content)
+	if err != nil {
+		return "", err
+	}
 
-		replaced := false
-		for _, match := range matches {
-			fullMatch := match[0]
-			varName := match[1]
-
-			// Check if this is a plugin call
-			if strings.HasPrefix(varName, "plugin:") {
-				pluginMatches := pluginPattern.FindStringSubmatch(fullMatch)
-				if len(pluginMatches) >= 3 {
-					namespace := pluginMatches[1]
-					operation := pluginMatches[2]
-					value := ""
-					if len(pluginMatches) == 4 {
-						value = pluginMatches[3]
-					}
-
-					debugf("\nPlugin call:\n")
-					debugf("  Namespace: %s\n", namespace)
-					debugf("  Operation: %s\n", operation)
-					debugf("  Value: %s\n", value)
-
-					var result string
-					var err error
-
-					switch namespace {
-					case "text":
-						debugf("Executing text plugin\n")
-						result, err = textPlugin.Apply(operation, value)
-					case "datetime":
-						debugf("Executing datetime plugin\n")
-						result, err = datetimePlugin.Apply(operation, value)
-					case "file":
-						debugf("Executing file plugin\n")
-						result, err = filePlugin.Apply(operation, value)
-						debugf("File plugin result: %#v\n", result)
-					case "fetch":
-						debugf("Executing fetch plugin\n")
-						result, err = fetchPlugin.Apply(operation, value)
-					case "sys":
-						debugf("Executing sys plugin\n")
-						result, err = sysPlugin.Apply(operation, value)
-					default:
-						return "", fmt.Errorf("unknown plugin namespace: %s", namespace)
-					}
-
-					if err != nil {
-						debugf("Plugin error: %v\n", err)
-						return "", fmt.Errorf("plugin %s error: %v", namespace, err)
-					}
-
-					debugf("Plugin result: %s\n", result)
-					content = strings.ReplaceAll(content, fullMatch, result)
-					debugf("Content after replacement: %s\n", content)
-					continue
-				}
-			}
+	e := &evaluator{src: content, variables: variables, input: input}
+	var sb strings.Builder
+	sb.Grow(len(content))
+	if err = e.evalNodes(nodes, nil, &sb); err != nil {
+		return "", err
+	}
+	if len(e.missing) > 0 {
+		return "", &MissingVariablesError{Names: e.missing}
+	}
 
-			if pluginMatches := extensionPattern.FindStringSubmatch(fullMatch); len(pluginMatches) >= 3 {
-				name := pluginMatches[1]
-				operation := pluginMatches[2]
-				value := ""
-				if len(pluginMatches) == 4 {
-					value = pluginMatches[3]
-				}
+	debugf("Template processing complete\n")
+	return sb.String(), nil
+}
 
-				debugf("\nExtension call:\n")
-				debugf("  Name: %s\n", name)
-				debugf("  Operation: %s\n", operation)
-				debugf("  Value: %s\n", value)
+// UsesInput reports whether content contains an {{input}} placeholder
+func UsesInput(content string) (ret bool) {
+	nodes, err := parse(content)
+	if err != nil {
+		return strings.Contains(content, "{{input}}")
+	}
+	walkVariables(nodes, func(name string) {
+		ret = ret || name == "input"
+	})
+	return
+}
 
-				result, err := extensionManager.ProcessExtension(name, operation, value)
-				if err != nil {
-					return "", fmt.Errorf("extension %s error: %v", name, err)
-				}
+// loopScope holds the current item of an {{#each}} block
+type loopScope struct {
+	item  string
+	index int
+}
 
-				content = strings.ReplaceAll(content, fullMatch, result)
-				replaced = true
-				continue
-			}
+// evaluator renders parsed template nodes
+type evaluator struct {
+	src       string
+	variables map[string]string
+	input     string
+	missing   []string
+	scoped    map[string]Plugin // instances of scoped plugins for this template
+}
 
-			// Handle regular variables and input
-			debugf("Processing variable: %s\n", varName)
-			if varName == "input" {
-				debugf("Replacing {{input}}\n")
-				replaced = true
-				content = strings.ReplaceAll(content, fullMatch, input)
+func (e *evaluator) evalNodes(nodes []*node, scope *loopScope, sb *strings.Builder) (err error) {
+	for _, n := range nodes {
+		switch n.kind {
+		case textNode:
+			sb.WriteString(n.text)
+		case tagNode:
+			var value string
+			var ok bool
+			if value, ok, err = e.evalTag(n, scope); err != nil {
+				return
+			}
+			if ok {
+				sb.WriteString(value)
+			}
+		case ifNode:
+			value, ok := e.lookup(n.text, scope)
+			if isTruthy(value, ok) {
+				err = e.evalNodes(n.body, scope, sb)
 			} else {
-				if val, ok := variables[varName]; !ok {
-					debugf("Missing variable: %s\n", varName)
-					missingVars = append(missingVars, varName)
-					return "", fmt.Errorf("missing required variable: %s", varName)
-				} else {
-					debugf("Replacing variable %s with value: %s\n", varName, val)
-					content = strings.ReplaceAll(content, fullMatch, val)
-					replaced = true
+				err = e.evalNodes(n.elseBody, scope, sb)
+			}
+			if err != nil {
+				return
+			}
+		case eachNode:
+			value, _ := e.lookup(n.text, scope)
+			items := splitList(value)
+			if len(items) == 0 {
+				if err = e.evalNodes(n.elseBody, scope, sb); err != nil {
+					return
 				}
+				continue
 			}
-			if !replaced {
-				return "", fmt.Errorf("template processing stuck - potential infinite loop")
+			for i, item := range items {
+				if err = e.evalNodes(n.body, &loopScope{item: item, index: i}, sb); err != nil {
+					return
+				}
 			}
 		}
 	}
+	return
+}
 
-	debugf("Starting template processing\n")
-	for strings.Contains(content, "{{") {
-		matches := r.FindAllStringSubmatch(content, -1)
-		if len(matches) == 0 {
-			break
+// evalTag evaluates a {{...}} expression. Nested tags are evaluated first and
+// their values become part of the expression. ok is false when a variable is
+// missing; missing variables are collected instead of failing right away.
+func (e *evaluator) evalTag(n *node, scope *loopScope) (ret string, ok bool, err error) {
+	var expr strings.Builder
+	complete := true
+	for _, part := range n.parts {
+		if part.kind == textNode {
+			expr.WriteString(part.text)
+			continue
+		}
+		var value string
+		var partOk bool
+		if value, partOk, err = e.evalTag(part, scope); err != nil {
+			return
+		}
+		if !partOk {
+			complete = false
+			continue
 		}
+		expr.WriteString(value)
+	}
+	if !complete {
+		return
+	}
 
-		replaced := false
-		for _, match := range matches {
-			fullMatch := match[0]
-			varName := match[1]
-
-			// Check if this is a plugin call
-			if strings.HasPrefix(varName, "plugin:") {
-				pluginMatches := pluginPattern.FindStringSubmatch(fullMatch)
-				if len(pluginMatches) >= 3 {
-					namespace := pluginMatches[1]
-					operation := pluginMatches[2]
-					value := ""
-					if len(pluginMatches) == 4 {
-						value = pluginMatches[3]
-					}
-
-					debugf("\nPlugin call:\n")
-					debugf("  Namespace: %s\n", namespace)
-					debugf("  Operation: %s\n", operation)
-					debugf("  Value: %s\n", value)
-
-					var result string
-					var err error
-
-					switch namespace {
-					case "text":
-						debugf("Executing text plugin\n")
-						result, err = textPlugin.Apply(operation, value)
-					case "datetime":
-						debugf("Executing datetime plugin\n")
-						result, err = datetimePlugin.Apply(operation, value)
-					case "file":
-						debugf("Executing file plugin\n")
-						result, err = filePlugin.Apply(operation, value)
-						debugf("File plugin result: %#v\n", result)
-					case "fetch":
-						debugf("Executing fetch plugin\n")
-						result, err = fetchPlugin.Apply(operation, value)
-					case "sys":
-						debugf("Executing sys plugin\n")
-						result, err = sysPlugin.Apply(operation, value)
-					default:
-						return "", fmt.Errorf("unknown plugin namespace: %s", namespace)
-					}
-
-					if err != nil {
-						debugf("Plugin error: %v\n", err)
-						return "", fmt.Errorf("plugin %s error: %v", namespace, err)
-					}
-
-					debugf("Plugin result: %s\n", result)
-					content = strings.ReplaceAll(content, fullMatch, result)
-					debugf("Content after replacement: %s\n", content)
-					continue
-				}
-			}
+	exprStr := expr.String()
+	switch {
+	case strings.HasPrefix(exprStr, pluginPrefix):
+		ret, err = e.callPlugin(exprStr[len(pluginPrefix):])
+	case strings.HasPrefix(exprStr, extensionPrefix):
+		ret, err = e.callExtension(exprStr[len(extensionPrefix):])
+	default:
+		var found bool
+		if ret, found, err = e.evalVariable(exprStr, scope); err == nil && !found {
+			return
+		}
+	}
+	if err != nil {
+		err = newPositionError(e.src, n.pos, err)
+		return
+	}
+	ok = true
+	return
+}
 
-			// Handle regular variables and input
-			debugf("Processing variable: %s\n", varName)
-			if varName == "input" {
-				debugf("Replacing {{input}}\n")
-				replaced = true
-				content = strings.ReplaceAll(content, fullMatch, input)
-			} else {
-				if val, ok := variables[varName]; !ok {
-					debugf("Missing variable: %s\n", varName)
-					missingVars = append(missingVars, varName)
-					return "", fmt.Errorf("missing required variable: %s", varName)
-				} else {
-					debugf("Replacing variable %s with value: %s\n", varName, val)
-					content = strings.ReplaceAll(content, fullMatch, val)
-					replaced = true
-				}
-			}
-			if !replaced {
-				return "", fmt.Errorf("template processing stuck - potential infinite loop")
+// evalVariable resolves a variable reference with optional filters
+func (e *evaluator) evalVariable(expr string, scope *loopScope) (ret string, found bool, err error) {
+	filters := splitFilters(expr)
+	if len(filters) == 0 {
+		return "", false, fmt.Errorf("empty placeholder")
+	}
+	name := filters[0]
+
+	debugf("Processing variable: %s\n", name)
+	if ret, found = e.lookup(name, scope); !found {
+		debugf("Missing variable: %s\n", name)
+		e.missing = appendMissing(e.missing, name)
+		return
+	}
+	ret, err = applyFilters(ret, filters[1:])
+	return
+}
+
+// lookup returns the value of a variable, {{input}} or a loop variable
+func (e *evaluator) lookup(name string, scope *loopScope) (ret string, ok bool) {
+	switch {
+	case name == "input":
+		return e.input, true
+	case scope != nil && name == loopItemVariable:
+		return scope.item, true
+	case scope != nil && name == loopIndexVariable:
+		return strconv.Itoa(scope.index), true
+	}
+	ret, ok = e.variables[name]
+	return
+}
+
+// callPlugin runs namespace:operation[:value]
+func (e *evaluator) callPlugin(call string) (ret string, err error) {
+	fields := strings.SplitN(call, ":", 3)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("invalid plugin call %q, expected plugin:namespace:operation[:value]", pluginPrefix+call)
+	}
+	namespace, operation := fields[0], fields[1]
+	value := ""
+	if len(fields) == 3 {
+		value = fields[2]
+	}
+
+	debugf("\nPlugin call:\n")
+	debugf("  Namespace: %s\n", namespace)
+	debugf("  Operation: %s\n", operation)
+	debugf("  Value: %s\n", value)
+
+	var plugin Plugin
+	if plugin, err = defaultPluginRegistry.Plugin(namespace); err != nil {
+		return
+	}
+	if scoped, ok := plugin.(ScopedPlugin); ok {
+		if e.scoped[namespace] == nil {
+			if e.scoped == nil {
+				e.scoped = make(map[string]Plugin)
 			}
+			e.scoped[namespace] = scoped.Scope()
 		}
+		plugin = e.scoped[namespace]
 	}
+	debugf("Executing %s plugin\n", namespace)
+	if ret, err = plugin.Apply(operation, value); err != nil {
+		debugf("Plugin error: %v\n", err)
+		return "", fmt.Errorf("plugin %s error: %v", namespace, err)
+	}
+	debugf("Plugin result: %s\n", ret)
+	return
+}
 
-	debugf("Template processing complete\n")
-	return content, nil
+// callExtension runs name:operation[:value]
+func (e *evaluator) callExtension(call string) (ret string, err error) {
+	fields := strings.SplitN(call, ":", 3)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("invalid extension call %q, expected ext:name:operation[:value]", extensionPrefix+call)
+	}
+	name, operation := fields[0], fields[1]
+	value := ""
+	if len(fields) == 3 {
+		value = fields[2]
+	}
+
+	debugf("\nExtension call:\n")
+	debugf("  Name: %s\n", name)
+	debugf("  Operation: %s\n", operation)
+	debugf("  Value: %s\n", value)
+
+	var plugin Plugin
+	if plugin, err = defaultPluginRegistry.Extension(name); err == nil {
+		ret, err = plugin.Apply(operation, value)
+	}
+	if err != nil {
+		return "", fmt.Errorf("extension %s error: %v", name, err)
+	}
+	return
+}
+
+// appendMissing adds name to missing unless it is already listed
+func appendMissing(missing []string, name string) []string {
+	for _, existing := range missing {
+		if existing == name {
+			return missing
+		}
+	}
+	return append(missing, name)
 }
@@ -106,6 +106,166 @@ func TestApplyTemplate(t *testing.T) {
 			errContains: "unknown plugin namespace",
 		},
 
+		// Conditionals
+		{
+			name:     "if with set variable",
+			template: "A{{#if focus}} focus on {{focus}}{{/if}}.",
+			vars:     map[string]string{"focus": "security"},
+			want:     "A focus on security.",
+		},
+		{
+			name:     "if with missing variable",
+			template: "A{{#if focus}} focus on {{focus}}{{/if}}.",
+			want:     "A.",
+		},
+		{
+			name:     "if with false value uses else",
+			template: "{{#if verbose}}long{{else}}short{{/if}}",
+			vars:     map[string]string{"verbose": "false"},
+			want:     "short",
+		},
+		{
+			name:     "nested if",
+			template: "{{#if a}}A{{#if b}}B{{else}}!B{{/if}}{{/if}}",
+			vars:     map[string]string{"a": "1", "b": ""},
+			want:     "A!B",
+		},
+
+		// Loops
+		{
+			name:     "each over comma separated list",
+			template: "{{#each topics}}- {{this}} ({{@index}})\n{{/each}}",
+			vars:     map[string]string{"topics": "go, rust"},
+			want:     "- go (0)\n- rust (1)\n",
+		},
+		{
+			name:     "each over JSON array with filter",
+			template: "{{#each names}}{{this | upper}};{{/each}}",
+			vars:     map[string]string{"names": `["ann", "bob"]`},
+			want:     "ANN;BOB;",
+		},
+		{
+			name:     "each over lines with outer variable",
+			template: "{{#each items}}{{prefix}}{{this}} {{/each}}",
+			vars:     map[string]string{"items": "a\nb\n", "prefix": "#"},
+			want:     "#a #b ",
+		},
+		{
+			name:     "each over empty list uses else",
+			template: "{{#each items}}{{this}}{{else}}none{{/each}}",
+			vars:     map[string]string{"items": ""},
+			want:     "none",
+		},
+		{
+			name:        "unclosed block",
+			template:    "{{#if a}}text",
+			wantErr:     true,
+			errContains: "unclosed {{#if a}}",
+		},
+		{
+			name:        "mismatched block",
+			template:    "{{#if a}}text{{/each}}",
+			wantErr:     true,
+			errContains: "closed by {{/each}}",
+		},
+
+		// Filters
+		{
+			name:     "variable filter pipeline",
+			template: "{{name | trim | upper}}!",
+			vars:     map[string]string{"name": "  world "},
+			want:     "WORLD!",
+		},
+		{
+			name:     "input filter",
+			template: "{{input|lower}}",
+			input:    "HELLO",
+			want:     "hello",
+		},
+		{
+			name:     "filter on empty value",
+			template: "[{{name | upper}}]",
+			vars:     map[string]string{"name": ""},
+			want:     "[]",
+		},
+		{
+			name:        "unknown filter",
+			template:    "{{name | reverse}}",
+			vars:        map[string]string{"name": "x"},
+			wantErr:     true,
+			errContains: "filter reverse",
+		},
+
+		// Escapes
+		{
+			name:     "escaped braces",
+			template: `Use \{{name}} for {{name}}`,
+			vars:     map[string]string{"name": "x"},
+			want:     "Use {{name}} for x",
+		},
+		{
+			name:     "raw block",
+			template: "{{#raw}}{{#if x}}{{y}}{{/raw}} {{name}}",
+			vars:     map[string]string{"name": "x"},
+			want:     "{{#if x}}{{y}} x",
+		},
+
+		// Values are inserted once and never re-evaluated
+		{
+			name:     "variable value with braces is inert",
+			template: "{{code}}",
+			vars:     map[string]string{"code": "{{name}} {{plugin:sys:hostname}}"},
+			want:     "{{name}} {{plugin:sys:hostname}}",
+		},
+		{
+			name:     "input with braces is inert",
+			template: "Input: {{input}}",
+			input:    "{{missing}} {{#if x}}",
+			want:     "Input: {{missing}} {{#if x}}",
+		},
+		{
+			name:     "plugin output with braces is inert",
+			template: "{{plugin:text:lower:{{value}}}}",
+			vars:     map[string]string{"value": "{{NAME}}"},
+			want:     "{{name}}",
+		},
+		{
+			name:     "single braces are text",
+			template: "func() { return {{name}} }",
+			vars:     map[string]string{"name": "1"},
+			want:     "func() { return 1 }",
+		},
+		{
+			name:     "unclosed placeholder is text",
+			template: "line one\nsee {{name and {{other}}",
+			vars:     map[string]string{"other": "x"},
+			want:     "line one\nsee {{name and x",
+		},
+		{
+			name:     "unclosed nested placeholder is text",
+			template: "{{a {{b}} {{",
+			vars:     map[string]string{"b": "x"},
+			want:     "{{a x {{",
+		},
+		{
+			name:        "unclosed block tag",
+			template:    "line one\nsee {{#if name",
+			wantErr:     true,
+			errContains: "line 2, column 5: unclosed {{",
+		},
+		{
+			name:        "plugin error position",
+			template:    "a\nb {{plugin:text:bad:x}}",
+			wantErr:     true,
+			errContains: "line 2, column 3: plugin text error",
+		},
+		{
+			name:        "stray closing tag",
+			template:    "{{/if}}",
+			wantErr:     true,
+			errContains: "line 1, column 1: unexpected {{/if}}",
+		},
+
 		// Edge cases
 		{
 			name:     "empty template",
@@ -143,3 +303,54 @@ func TestApplyTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestUsesInput(t *testing.T) {
+	tests := map[string]bool{
+		"{{input}}":                       true,
+		"{{ input | trim }}":              true,
+		"{{#if x}}{{input}}{{/if}}":       true,
+		"no input":                        false,
+		`\\{{input}}`:                     false,
+		"{{#raw}}{{input}}{{/raw}}":       false,
+		"{{plugin:text:upper:{{input}}}}": true,
+	}
+	for content, want := range tests {
+		if got := UsesInput(content); got != want {
+			t.Errorf("UsesInput(%q) = %v, want %v", content, got, want)
+		}
+	}
+}
+
+// largeTranscript builds a multi-megabyte text that resembles a transcript
+func largeTranscript(size int) string {
+	line := "[00:12:34] Speaker: this is a line of a long meeting transcript with {curly} braces\n"
+	return strings.Repeat(line, size/len(line)+1)
+}
+
+func BenchmarkApplyTemplateLargeInput(b *testing.B) {
+	pattern := "# IDENTITY\nYou are a {{role}}.\n{{#if focus}}Focus on {{focus}}.{{/if}}\n# INPUT\n{{input}}"
+	vars := map[string]string{"role": "summarizer", "focus": "decisions"}
+	input := largeTranscript(5 << 20)
+
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ApplyTemplate(pattern, vars, input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkApplyTemplateManyPlaceholders(b *testing.B) {
+	chunk := strings.Repeat("x", 1000) + " {{name | upper}} {{plugin:text:lower:ABC}}\n"
+	content := strings.Repeat(chunk, (5<<20)/len(chunk))
+	vars := map[string]string{"name": "fabric"}
+
+	b.SetBytes(int64(len(content)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ApplyTemplate(content, vars, ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
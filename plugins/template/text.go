@@ -29,6 +29,26 @@ func toTitle(s string) string {
 	return string(runes)
 }
 
+// Name returns the template namespace of the plugin
+func (p *TextPlugin) Name() string {
+	return "text"
+}
+
+// Description returns a short description of the plugin
+func (p *TextPlugin) Description() string {
+	return "String manipulation operations"
+}
+
+// Operations lists the supported text operations
+func (p *TextPlugin) Operations() []Operation {
+	return []Operation{
+		{Name: "upper", Usage: pluginUsage("text", "upper", "VALUE"), Description: "Convert to upper case"},
+		{Name: "lower", Usage: pluginUsage("text", "lower", "VALUE"), Description: "Convert to lower case"},
+		{Name: "title", Usage: pluginUsage("text", "title", "VALUE"), Description: "Capitalize the first letter of each word"},
+		{Name: "trim", Usage: pluginUsage("text", "trim", "VALUE"), Description: "Remove leading and trailing whitespace"},
+	}
+}
+
 // Apply executes the requested text operation on the provided value
 func (p *TextPlugin) Apply(operation string, value string) (string, error) {
 	debugf("TextPlugin: operation=%s value=%q", operation, value)
@@ -39,3 +39,19 @@ func ExpandPath(path string) (string, error) {
 
 	return absPath, nil
 }
+
+// splitArgument splits "ARG:DATA" at the first colon that is not escaped as
+// \:, so data extracted from files or fetched pages can contain colons
+func splitArgument(value string) (arg string, data string, ok bool) {
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			if i+1 < len(value) && value[i+1] == ':' {
+				i++
+			}
+		case ':':
+			return strings.ReplaceAll(value[:i], `\:`, ":"), value[i+1:], true
+		}
+	}
+	return strings.ReplaceAll(value, `\:`, ":"), "", false
+}
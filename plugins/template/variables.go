@@ -0,0 +1,222 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported variable types for pattern variable declarations
+const (
+	VariableTypeString = "string"
+	VariableTypeInt    = "int"
+	VariableTypeFloat  = "float"
+	VariableTypeBool   = "bool"
+)
+
+// VariableDef describes a pattern variable as declared in pattern metadata
+type VariableDef struct {
+	Name        string   `yaml:"name"`
+	Type        string   `yaml:"type"`
+	Default     *string  `yaml:"default"`
+	Enum        []string `yaml:"enum"`
+	Description string   `yaml:"description"`
+}
+
+// VariablePrompter asks the user for the values of missing variables.
+// It returns the collected values keyed by variable name.
+type VariablePrompter func(missing []VariableDef) (map[string]string, error)
+
+// MissingVariablesError reports every variable that has no value
+type MissingVariablesError struct {
+	Names []string
+}
+
+func (e *MissingVariablesError) Error() string {
+	if len(e.Names) == 1 {
+		return fmt.Sprintf("missing required variable: %s", e.Names[0])
+	}
+	return fmt.Sprintf("missing required variables: %s", strings.Join(e.Names, ", "))
+}
+
+// ReferencedVariables returns the names of plain variables used in content,
+// in order of first appearance. Plugin and extension calls, block tags,
+// loop variables, escaped text and {{input}} are not included.
+func ReferencedVariables(content string) (ret []string) {
+	nodes, err := parse(content)
+	if err != nil {
+		// ApplyTemplate reports the syntax error
+		return
+	}
+	seen := make(map[string]bool)
+	walkVariables(nodes, func(name string) {
+		if seen[name] || strings.ContainsAny(name, " \t\r\n") {
+			return
+		}
+		switch name {
+		case "input", loopItemVariable, loopIndexVariable:
+			return
+		}
+		seen[name] = true
+		ret = append(ret, name)
+	})
+	return
+}
+
+// LoadVariableFiles returns the values with those starting with @ replaced
+// by the content of the named file (@@ escapes a literal @). It is meant for
+// values given on the command line, never for ones received over the network.
+func LoadVariableFiles(values map[string]string) (ret map[string]string, err error) {
+	if values == nil {
+		return
+	}
+	ret = make(map[string]string, len(values))
+	for name, value := range values {
+		if ret[name], err = loadVariableValue(name, value); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// ResolveVariables builds the final variable set for content. Provided values
+// are used as they are, declared defaults fill the gaps, and any variables
+// still missing are requested from prompt when it is not nil; prompted values
+// may load files like LoadVariableFiles. All missing variables are reported
+// together, and values are validated against their declared type and enum.
+func ResolveVariables(
+	content string, defs []VariableDef, values map[string]string, prompt VariablePrompter) (ret map[string]string, err error) {
+
+	ret = make(map[string]string, len(values))
+	for name, value := range values {
+		ret[name] = value
+	}
+
+	for _, def := range defs {
+		if _, ok := ret[def.Name]; !ok && def.Default != nil {
+			ret[def.Name] = *def.Default
+		}
+	}
+
+	missing := missingVariables(content, defs, ret)
+	if len(missing) > 0 && prompt != nil {
+		var prompted map[string]string
+		if prompted, err = prompt(missing); err != nil {
+			return nil, err
+		}
+		for name, value := range prompted {
+			if ret[name], err = loadVariableValue(name, value); err != nil {
+				return nil, err
+			}
+		}
+		missing = missingVariables(content, defs, ret)
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, len(missing))
+		for i, def := range missing {
+			names[i] = def.Name
+		}
+		return nil, &MissingVariablesError{Names: names}
+	}
+
+	var invalid []string
+	for _, def := range defs {
+		value, ok := ret[def.Name]
+		if !ok {
+			continue // declared but not used by content
+		}
+		if validateErr := def.Validate(value); validateErr != nil {
+			invalid = append(invalid, validateErr.Error())
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return nil, fmt.Errorf("invalid variable values: %s", strings.Join(invalid, "; "))
+	}
+	return
+}
+
+// Validate checks value against the declared type and enum of the variable
+func (d *VariableDef) Validate(value string) error {
+	if len(d.Enum) > 0 {
+		found := false
+		for _, allowed := range d.Enum {
+			if value == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %q is not one of %s", d.Name, value, strings.Join(d.Enum, ", "))
+		}
+	}
+
+	var err error
+	switch d.Type {
+	case "", VariableTypeString:
+	case VariableTypeInt:
+		_, err = strconv.Atoi(value)
+	case VariableTypeFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case VariableTypeBool:
+		_, err = strconv.ParseBool(value)
+	default:
+		return fmt.Errorf("%s: unknown variable type %q", d.Name, d.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a valid %s", d.Name, value, d.Type)
+	}
+	return nil
+}
+
+// missingVariables returns the variables referenced in content without a
+// value, declared ones first. Declared variables content does not use are
+// not required.
+func missingVariables(content string, defs []VariableDef, values map[string]string) (ret []VariableDef) {
+	referenced := ReferencedVariables(content)
+	used := make(map[string]bool, len(referenced))
+	for _, name := range referenced {
+		used[name] = true
+	}
+	declared := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		declared[def.Name] = true
+		if _, ok := values[def.Name]; !ok && used[def.Name] {
+			ret = append(ret, def)
+		}
+	}
+	for _, name := range referenced {
+		if _, ok := values[name]; !ok && !declared[name] {
+			ret = append(ret, VariableDef{Name: name})
+		}
+	}
+	return
+}
+
+// loadVariableValue resolves @path values to the content of the file,
+// values naming no existing file such as @handle are kept as they are
+func loadVariableValue(name, value string) (string, error) {
+	if strings.HasPrefix(value, "@@") {
+		return value[1:], nil
+	}
+	if !strings.HasPrefix(value, "@") || len(value) == 1 {
+		return value, nil
+	}
+
+	path, err := ExpandPath(value[1:])
+	if errors.Is(err, os.ErrNotExist) {
+		return value, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("variable %s: %w", name, err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("variable %s: failed to read file: %w", name, err)
+	}
+	return string(content), nil
+}
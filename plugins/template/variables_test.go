@@ -0,0 +1,205 @@
+package template
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestLoadVariableFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	notesPath := filepath.Join(tmpDir, "notes.md")
+	if err := os.WriteFile(notesPath, []byte("file notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadVariableFiles(map[string]string{
+		"role":    "expert",
+		"notes":   "@" + notesPath,
+		"escaped": "@@fabric",
+		"handle":  "@fabric",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"role": "expert", "notes": "file notes", "escaped": "@fabric", "handle": "@fabric"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadVariableFiles() = %v, want %v", got, want)
+	}
+
+	// files that exist but cannot be read fail
+	if _, err = LoadVariableFiles(map[string]string{"notes": "@" + tmpDir}); err == nil || !strings.Contains(err.Error(), "variable notes") {
+		t.Errorf("LoadVariableFiles() error = %v, want an error for variable notes", err)
+	}
+}
+
+func TestResolveVariables(t *testing.T) {
+	tmpDir := t.TempDir()
+	notesPath := filepath.Join(tmpDir, "notes.md")
+	if err := os.WriteFile(notesPath, []byte("file notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		content     string
+		defs        []VariableDef
+		values      map[string]string
+		prompt      VariablePrompter
+		want        map[string]string
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:    "provided values pass through",
+			content: "{{role}}",
+			values:  map[string]string{"role": "expert"},
+			want:    map[string]string{"role": "expert"},
+		},
+		{
+			name:    "default applied",
+			content: "{{lang}}",
+			defs:    []VariableDef{{Name: "lang", Default: strPtr("en")}},
+			want:    map[string]string{"lang": "en"},
+		},
+		{
+			name:    "empty default makes variable optional",
+			content: "{{focus}}",
+			defs:    []VariableDef{{Name: "focus", Default: strPtr("")}},
+			want:    map[string]string{"focus": ""},
+		},
+		{
+			name:    "provided files are not loaded",
+			content: "{{notes}}",
+			values:  map[string]string{"notes": "@" + notesPath},
+			want:    map[string]string{"notes": "@" + notesPath},
+		},
+		{
+			name:    "prompted value loaded from file",
+			content: "{{notes}}",
+			prompt: func(missing []VariableDef) (map[string]string, error) {
+				return map[string]string{"notes": "@" + notesPath}, nil
+			},
+			want: map[string]string{"notes": "file notes"},
+		},
+		{
+			name:        "all missing variables reported",
+			content:     "{{a}} {{b}} {{c}} {{input}} {{plugin:text:upper:x}}",
+			defs:        []VariableDef{{Name: "c"}},
+			wantErr:     true,
+			errContains: "missing required variables: c, a, b",
+		},
+		{
+			name:    "unused declared variable not required",
+			content: "{{a}}",
+			defs:    []VariableDef{{Name: "unused", Type: VariableTypeInt}},
+			values:  map[string]string{"a": "1"},
+			want:    map[string]string{"a": "1"},
+		},
+		{
+			name:    "prompt fills missing variables",
+			content: "{{a}} {{b}}",
+			values:  map[string]string{"a": "1"},
+			prompt: func(missing []VariableDef) (map[string]string, error) {
+				if len(missing) != 1 || missing[0].Name != "b" {
+					return nil, errors.New("unexpected missing variables")
+				}
+				return map[string]string{"b": "2"}, nil
+			},
+			want: map[string]string{"a": "1", "b": "2"},
+		},
+		{
+			name:    "prompt left value empty",
+			content: "{{a}}",
+			prompt: func(missing []VariableDef) (map[string]string, error) {
+				return map[string]string{}, nil
+			},
+			wantErr:     true,
+			errContains: "missing required variable: a",
+		},
+		{
+			name:        "invalid int",
+			content:     "{{points}}",
+			defs:        []VariableDef{{Name: "points", Type: VariableTypeInt}},
+			values:      map[string]string{"points": "many"},
+			wantErr:     true,
+			errContains: `points: "many" is not a valid int`,
+		},
+		{
+			name:    "valid typed values",
+			content: "{{points}} {{ratio}} {{verbose}}",
+			defs: []VariableDef{
+				{Name: "points", Type: VariableTypeInt},
+				{Name: "ratio", Type: VariableTypeFloat},
+				{Name: "verbose", Type: VariableTypeBool},
+			},
+			values: map[string]string{"points": "3", "ratio": "0.5", "verbose": "true"},
+			want:   map[string]string{"points": "3", "ratio": "0.5", "verbose": "true"},
+		},
+		{
+			name:        "value outside enum",
+			content:     "{{lang}}",
+			defs:        []VariableDef{{Name: "lang", Enum: []string{"en", "de"}}},
+			values:      map[string]string{"lang": "fr"},
+			wantErr:     true,
+			errContains: `lang: "fr" is not one of en, de`,
+		},
+		{
+			name:        "unknown type",
+			content:     "{{x}}",
+			defs:        []VariableDef{{Name: "x", Type: "date"}},
+			values:      map[string]string{"x": "today"},
+			wantErr:     true,
+			errContains: `unknown variable type "date"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveVariables(tt.content, tt.defs, tt.values, tt.prompt)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveVariables() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("error %q should contain %q", err.Error(), tt.errContains)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveVariables() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyTemplateReportsAllMissingVariables(t *testing.T) {
+	_, err := ApplyTemplate("{{a}} {{b}} {{a}}", nil, "")
+
+	var missingErr *MissingVariablesError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("expected MissingVariablesError, got %v", err)
+	}
+	if !reflect.DeepEqual(missingErr.Names, []string{"a", "b"}) {
+		t.Errorf("missing = %v, want [a b]", missingErr.Names)
+	}
+}
+
+func TestReferencedVariables(t *testing.T) {
+	content := "{{a}} {{b | upper}} {{#if c}}{{d}}{{/if}} {{#each e}}{{this}}{{@index}}{{/each}} " +
+		"{{input}} {{plugin:text:upper:x}} \\{{f}} {{#raw}}{{g}}{{/raw}} {{a}}"
+
+	got := ReferencedVariables(content)
+	want := []string{"a", "b", "d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReferencedVariables() = %v, want %v", got, want)
+	}
+}
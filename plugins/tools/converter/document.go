@@ -0,0 +1,321 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"golang.org/x/net/html"
+)
+
+// MIME types of the documents text is extracted from
+const (
+	MimeTypePDF   = "application/pdf"
+	MimeTypeDOCX  = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MimeTypeEPUB  = "application/epub+zip"
+	MimeTypeHTML  = "text/html"
+	MimeTypeXHTML = "application/xhtml+xml"
+)
+
+// maxArchiveEntrySize limits the decompressed size of a file in a DOCX or
+// EPUB archive
+const maxArchiveEntrySize = 64 * 1024 * 1024
+
+// textMimeTypes are sent as they are, besides text/*
+var textMimeTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/x-yaml":     true,
+	"application/yaml":       true,
+	"application/javascript": true,
+	"application/x-sh":       true,
+	"application/sql":        true,
+}
+
+// IsDocument reports whether text can be extracted from content of the MIME
+// type, parameters like the charset are ignored
+func IsDocument(mimeType string) bool {
+	mediaType := baseMimeType(mimeType)
+	switch mediaType {
+	case MimeTypePDF, MimeTypeDOCX, MimeTypeEPUB, MimeTypeHTML, MimeTypeXHTML:
+		return true
+	}
+	return strings.HasPrefix(mediaType, "text/") || textMimeTypes[mediaType]
+}
+
+// ExtractText returns the text of a document in pure Go: PDF, DOCX, EPUB,
+// HTML and plain text formats are supported
+func ExtractText(content []byte, mimeType string) (ret string, err error) {
+	switch mediaType := baseMimeType(mimeType); {
+	case mediaType == MimeTypePDF:
+		ret, err = extractPDF(content)
+	case mediaType == MimeTypeDOCX:
+		ret, err = extractDOCX(content)
+	case mediaType == MimeTypeEPUB:
+		ret, err = extractEPUB(content)
+	case mediaType == MimeTypeHTML || mediaType == MimeTypeXHTML:
+		ret, err = HtmlToText(content)
+	case IsDocument(mediaType):
+		ret = string(content)
+	default:
+		err = fmt.Errorf("cannot extract text from %s", mimeType)
+	}
+	if err == nil {
+		ret = strings.TrimSpace(ret)
+	}
+	return
+}
+
+func baseMimeType(mimeType string) string {
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return mediaType
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+func extractPDF(content []byte) (ret string, err error) {
+	// the parser panics on some malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid PDF: %v", r)
+		}
+	}()
+
+	var reader *pdf.Reader
+	if reader, err = pdf.NewReader(bytes.NewReader(content), int64(len(content))); err != nil {
+		err = fmt.Errorf("invalid PDF: %w", err)
+		return
+	}
+
+	var pages []string
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		var text string
+		if text, err = page.GetPlainText(nil); err != nil {
+			err = fmt.Errorf("could not read page %d: %w", i, err)
+			return
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			pages = append(pages, text)
+		}
+	}
+	ret = strings.Join(pages, "\n\n")
+	return
+}
+
+func extractDOCX(content []byte) (ret string, err error) {
+	var archive *zip.Reader
+	if archive, err = zip.NewReader(bytes.NewReader(content), int64(len(content))); err != nil {
+		err = fmt.Errorf("invalid DOCX: %w", err)
+		return
+	}
+
+	var document []byte
+	if document, err = readArchiveFile(archive, "word/document.xml"); err != nil {
+		return
+	}
+
+	// paragraphs are w:p elements, their text is in w:t elements
+	var builder strings.Builder
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	inText := false
+	for {
+		var token xml.Token
+		if token, err = decoder.Token(); err != nil {
+			if err == io.EOF {
+				err = nil
+				break
+			}
+			err = fmt.Errorf("invalid DOCX: %w", err)
+			return
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				builder.WriteString("\t")
+			case "br", "cr":
+				builder.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				builder.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				builder.Write(element)
+			}
+		}
+	}
+	ret = builder.String()
+	return
+}
+
+func extractEPUB(content []byte) (ret string, err error) {
+	var archive *zip.Reader
+	if archive, err = zip.NewReader(bytes.NewReader(content), int64(len(content))); err != nil {
+		err = fmt.Errorf("invalid EPUB: %w", err)
+		return
+	}
+
+	var containerXML []byte
+	if containerXML, err = readArchiveFile(archive, "META-INF/container.xml"); err != nil {
+		return
+	}
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err = xml.Unmarshal(containerXML, &container); err != nil || len(container.Rootfiles) == 0 {
+		err = fmt.Errorf("invalid EPUB container: %v", err)
+		return
+	}
+
+	packagePath := container.Rootfiles[0].FullPath
+	var packageXML []byte
+	if packageXML, err = readArchiveFile(archive, packagePath); err != nil {
+		return
+	}
+	var opf struct {
+		Items []struct {
+			ID   string `xml:"id,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"manifest>item"`
+		ItemRefs []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"spine>itemref"`
+	}
+	if err = xml.Unmarshal(packageXML, &opf); err != nil {
+		err = fmt.Errorf("invalid EPUB package: %w", err)
+		return
+	}
+
+	hrefs := make(map[string]string, len(opf.Items))
+	for _, item := range opf.Items {
+		hrefs[item.ID] = item.Href
+	}
+
+	// the chapters in reading order
+	var chapters []string
+	for _, itemRef := range opf.ItemRefs {
+		href, ok := hrefs[itemRef.IDRef]
+		if !ok {
+			continue
+		}
+		var chapter []byte
+		if chapter, err = readArchiveFile(archive, path.Join(path.Dir(packagePath), href)); err != nil {
+			return
+		}
+		var text string
+		if text, err = HtmlToText(chapter); err != nil {
+			return
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			chapters = append(chapters, text)
+		}
+	}
+	ret = strings.Join(chapters, "\n\n")
+	return
+}
+
+func readArchiveFile(archive *zip.Reader, name string) (ret []byte, err error) {
+	var file io.ReadCloser
+	if file, err = archive.Open(name); err != nil {
+		err = fmt.Errorf("missing %s: %w", name, err)
+		return
+	}
+	defer file.Close()
+
+	if ret, err = io.ReadAll(io.LimitReader(file, maxArchiveEntrySize+1)); err != nil {
+		return
+	}
+	if len(ret) > maxArchiveEntrySize {
+		err = fmt.Errorf("%s exceeds %d bytes", name, maxArchiveEntrySize)
+	}
+	return
+}
+
+// blockElements start a new line in the text of HTML
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "pre": true, "blockquote": true, "section": true,
+	"article": true, "header": true, "footer": true, "table": true, "ul": true, "ol": true, "hr": true,
+}
+
+// HtmlToText returns the visible text of an HTML document, block elements
+// are separated by new lines
+func HtmlToText(content []byte) (ret string, err error) {
+	var builder strings.Builder
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+	skip := 0
+	for {
+		switch tokenType := tokenizer.Next(); tokenType {
+		case html.ErrorToken:
+			if err = tokenizer.Err(); err == io.EOF {
+				err = nil
+			}
+			ret = collapseBlankLines(builder.String())
+			return
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			switch tag := string(name); {
+			case tag == "script" || tag == "style" || tag == "head" || tag == "noscript":
+				// <script src="a.js"/> has no content and no end tag
+				if tokenType == html.StartTagToken {
+					skip++
+				} else {
+					tokenizer.NextIsNotRawText()
+				}
+			case blockElements[tag]:
+				builder.WriteString("\n")
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch tag := string(name); {
+			case tag == "script" || tag == "style" || tag == "head" || tag == "noscript":
+				skip = max(skip-1, 0)
+			case blockElements[tag]:
+				builder.WriteString("\n")
+			}
+		case html.TextToken:
+			if skip == 0 {
+				builder.WriteString(strings.Join(strings.Fields(string(tokenizer.Text())), " "))
+				builder.WriteString(" ")
+			}
+		}
+	}
+}
+
+func collapseBlankLines(text string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if !blank && len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		lines = append(lines, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,147 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, content := range files {
+		file, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = file.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func TestIsDocument(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		expected bool
+	}{
+		{MimeTypePDF, true},
+		{MimeTypeDOCX, true},
+		{MimeTypeEPUB, true},
+		{"text/html; charset=utf-8", true},
+		{MimeTypeXHTML, true},
+		{"text/plain; charset=utf-8", true},
+		{"application/json", true},
+		{"image/png", false},
+		{"application/octet-stream", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.mimeType, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsDocument(tc.mimeType))
+		})
+	}
+}
+
+func TestExtractText(t *testing.T) {
+	docx := buildZip(t, map[string]string{
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Hello</w:t></w:r><w:r><w:t xml:space="preserve"> World</w:t></w:r></w:p>
+<w:p><w:r><w:t>Second</w:t><w:tab/><w:t>paragraph</w:t></w:r></w:p>
+</w:body></w:document>`,
+	})
+	epub := buildZip(t, map[string]string{
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`,
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+<manifest>
+<item id="ch2" href="text/ch2.xhtml" media-type="application/xhtml+xml"/>
+<item id="ch1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+</manifest>
+<spine><itemref idref="ch1"/><itemref idref="ch2"/></spine>
+</package>`,
+		"OEBPS/text/ch1.xhtml": `<html><head><title>One</title></head><body><h1>Chapter 1</h1><p>It begins.</p></body></html>`,
+		"OEBPS/text/ch2.xhtml": `<html><body><h1>Chapter 2</h1><p>It ends.</p></body></html>`,
+	})
+
+	tests := []struct {
+		name     string
+		content  []byte
+		mimeType string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "DOCX",
+			content:  docx,
+			mimeType: MimeTypeDOCX,
+			expected: "Hello World\nSecond\tparagraph",
+		},
+		{
+			name:     "EPUB in spine order",
+			content:  epub,
+			mimeType: MimeTypeEPUB,
+			expected: "Chapter 1\n\nIt begins.\n\nChapter 2\n\nIt ends.",
+		},
+		{
+			name:     "HTML without scripts and styles",
+			content:  []byte(`<html><head><style>p {}</style></head><body><p>Hello   <b>there</b></p><script>alert(1)</script><ul><li>One</li><li>Two</li></ul></body></html>`),
+			mimeType: "text/html; charset=utf-8",
+			expected: "Hello there\n\nOne\n\nTwo",
+		},
+		{
+			name:     "HTML with self-closing script",
+			content:  []byte(`<head/><script src="a.js"/><p>text</p>`),
+			mimeType: "text/html",
+			expected: "text",
+		},
+		{
+			name:     "XHTML",
+			content:  []byte(`<?xml version="1.0"?><html xmlns="http://www.w3.org/1999/xhtml"><body><p>Hello</p></body></html>`),
+			mimeType: MimeTypeXHTML,
+			expected: "Hello",
+		},
+		{
+			name:     "Plain text",
+			content:  []byte("  Just text\n"),
+			mimeType: "text/plain; charset=utf-8",
+			expected: "Just text",
+		},
+		{
+			name:     "Invalid PDF",
+			content:  []byte("not a pdf"),
+			mimeType: MimeTypePDF,
+			err:      true,
+		},
+		{
+			name:     "DOCX without document",
+			content:  buildZip(t, map[string]string{"other.xml": "<a/>"}),
+			mimeType: MimeTypeDOCX,
+			err:      true,
+		},
+		{
+			name:     "Unsupported type",
+			content:  []byte{0x89, 'P', 'N', 'G'},
+			mimeType: "image/png",
+			err:      true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			text, err := ExtractText(tc.content, tc.mimeType)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, text)
+		})
+	}
+}
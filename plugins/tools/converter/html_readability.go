@@ -2,6 +2,7 @@ package converter
 
 import (
 	"bytes"
+	"net/url"
 
 	"github.com/go-shiori/go-readability"
 )
@@ -24,3 +25,15 @@ func HtmlReadability(html string) (ret string, err error) {
 	ret = article.TextContent
 	return
 }
+
+// HtmlReadabilityArticle returns the title and the main content of a web page
+// as HTML, relative links are resolved against pageURL
+func HtmlReadabilityArticle(html string, pageURL *url.URL) (title string, content string, err error) {
+	var article readability.Article
+	if article, err = readability.FromReader(bytes.NewBufferString(html), pageURL); err != nil {
+		return
+	}
+	title = article.Title
+	content = article.Content
+	return
+}
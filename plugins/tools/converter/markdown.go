@@ -0,0 +1,20 @@
+package converter
+
+import (
+	"strings"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/JohannesKaufmann/html-to-markdown/plugin"
+)
+
+// HtmlToMarkdown converts HTML to GitHub flavored Markdown, keeping headings,
+// links, lists, tables and fenced code blocks
+func HtmlToMarkdown(html string) (ret string, err error) {
+	converter := md.NewConverter("", true, &md.Options{CodeBlockStyle: "fenced"})
+	converter.Use(plugin.GitHubFlavored())
+	if ret, err = converter.ConvertString(html); err != nil {
+		return
+	}
+	ret = strings.TrimSpace(ret)
+	return
+}
@@ -0,0 +1,44 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHtmlToMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "Headings and links",
+			html:     `<h2>Title</h2><p>See <a href="https://example.com/docs">the docs</a>.</p>`,
+			expected: "## Title\n\nSee [the docs](https://example.com/docs).",
+		},
+		{
+			name:     "Lists",
+			html:     `<ol><li>One</li><li>Two</li></ol>`,
+			expected: "1. One\n2. Two",
+		},
+		{
+			name:     "Tables",
+			html:     `<table><tr><th>Name</th><th>Value</th></tr><tr><td>a</td><td>1</td></tr></table>`,
+			expected: "| Name | Value |\n| --- | --- |\n| a | 1 |",
+		},
+		{
+			name:     "Code blocks",
+			html:     "<pre><code class=\"language-go\">func main() {}</code></pre>",
+			expected: "```go\nfunc main() {}\n```",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := HtmlToMarkdown(tc.html)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
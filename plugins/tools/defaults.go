@@ -29,6 +29,12 @@ func NeeDefaults(getVendorsModels func() (*ai.VendorsModels, error)) (ret *Defau
 	ret.ModelContextLength = ret.AddSetupQuestionCustom("Model Context Length", false,
 		"Enter model context length")
 
+	ret.EmbeddingModel = ret.AddSetupQuestionCustom("Embedding Model", false,
+		"Enter the name of your default embedding model (optional, used by --embed)")
+
+	ret.TranscriptionModel = ret.AddSetupQuestionCustom("Transcription Model", false,
+		"Enter the name of your default transcription model, e.g. whisper-1 (optional, used by --transcribe)")
+
 	return
 }
 
@@ -38,6 +44,8 @@ type Defaults struct {
 	Vendor             *plugins.Setting
 	Model              *plugins.SetupQuestion
 	ModelContextLength *plugins.SetupQuestion
+	EmbeddingModel     *plugins.SetupQuestion
+	TranscriptionModel *plugins.SetupQuestion
 	GetVendorsModels   func() (*ai.VendorsModels, error)
 }
 
@@ -26,6 +26,10 @@ type FetchOptions struct {
 	// SingleDirectory if true, only fetch files directly in the specified directory
 	// without recursing into subdirectories
 	SingleDirectory bool
+
+	// ExtraFiles maps files outside of PathPrefix to the local paths they
+	// are saved to
+	ExtraFiles map[string]string
 }
 
 // FetchFilesFromRepo clones a git repo and extracts files from a specific folder
@@ -69,6 +73,10 @@ func FetchFilesFromRepo(opts FetchOptions) error {
 
 	// Extract files from the tree
 	return tree.Files().ForEach(func(f *object.File) error {
+		if localPath, ok := opts.ExtraFiles[f.Name]; ok {
+			return writeFile(f, localPath)
+		}
+
 		// Only process files in the specified path
 		if !strings.HasPrefix(f.Name, opts.PathPrefix) {
 			return nil
@@ -84,28 +92,31 @@ func FetchFilesFromRepo(opts FetchOptions) error {
 
 		// Create local path for the file, removing the prefix
 		relativePath := strings.TrimPrefix(f.Name, opts.PathPrefix)
-		localPath := filepath.Join(opts.DestDir, relativePath)
-
-		// Ensure directory structure exists
-		if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
-			return err
-		}
+		return writeFile(f, filepath.Join(opts.DestDir, relativePath))
+	})
+}
 
-		// Get file contents
-		reader, err := f.Reader()
-		if err != nil {
-			return err
-		}
-		defer reader.Close()
+// writeFile saves a file of the repository to localPath
+func writeFile(f *object.File, localPath string) error {
+	// Ensure directory structure exists
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return err
+	}
 
-		// Create and write to local file
-		file, err := os.Create(localPath)
-		if err != nil {
-			return err
-		}
-		defer file.Close()
+	// Get file contents
+	reader, err := f.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
 
-		_, err = io.Copy(file, reader)
+	// Create and write to local file
+	file, err := os.Create(localPath)
+	if err != nil {
 		return err
-	})
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, reader)
+	return err
 }
@@ -15,6 +15,9 @@ import (
 const DefaultPatternsGitRepoUrl = "https://github.com/danielmiessler/fabric.git"
 const DefaultPatternsGitRepoFolder = "patterns"
 
+// PatternDescriptionsRepoFile holds the curated pattern descriptions
+const PatternDescriptionsRepoFile = "Pattern_Descriptions/pattern_descriptions.json"
+
 func NewPatternsLoader(patterns *fsdb.PatternsEntity) (ret *PatternsLoader) {
 	label := "Patterns Loader"
 	ret = &PatternsLoader{
@@ -152,6 +155,7 @@ func (o *PatternsLoader) gitCloneAndCopy() (err error) {
 		RepoURL:    o.DefaultGitRepoUrl.Value,
 		PathPrefix: o.DefaultFolder.Value,
 		DestDir:    o.tempPatternsFolder,
+		ExtraFiles: map[string]string{PatternDescriptionsRepoFile: o.Patterns.DescriptionsFilePath},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to download patterns: %w", err)
@@ -0,0 +1,138 @@
+package scraper
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/plugins"
+	"github.com/danielmiessler/fabric/plugins/tools/converter"
+)
+
+// Scraper returns the main content of a web page as Markdown
+type Scraper interface {
+	ScrapeURL(url string) (string, error)
+}
+
+// Backends of --scrape_url
+const (
+	BackendLocal = "local"
+	BackendJina  = "jina"
+)
+
+// MaxPageSize limits the pages downloaded by the local scraper
+const MaxPageSize = 10 * 1024 * 1024
+
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+
+func NewClient() (ret *Client) {
+
+	label := "Web Scraper"
+
+	ret = &Client{
+		PluginBase: &plugins.PluginBase{
+			Name:             label,
+			SetupDescription: "Web Scraper - to grab a webpage as Markdown locally or with Jina AI",
+			EnvNamePrefix:    plugins.BuildEnvVariablePrefix(label),
+		},
+		HttpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	ret.DefaultBackend = ret.AddSetupQuestionCustom("Default Backend", false,
+		"Enter the default backend of --scrape_url, local or jina (optional, Jina AI is used when its API key is set and local otherwise)")
+	ret.ConfigureCustom = ret.configure
+
+	return
+}
+
+// Client is the local scraper, it fetches the page itself and keeps the main
+// content found by readability
+type Client struct {
+	*plugins.PluginBase
+	DefaultBackend *plugins.SetupQuestion
+	HttpClient     *http.Client
+}
+
+func (o *Client) configure() (err error) {
+	switch o.DefaultBackend.Value {
+	case "", BackendLocal, BackendJina:
+	default:
+		err = fmt.Errorf("unknown scraper backend %s, use %s or %s", o.DefaultBackend.Value, BackendLocal, BackendJina)
+	}
+	return
+}
+
+// ScrapeURL returns the main content of the web page as Markdown with the
+// page title as heading, text content other than HTML is returned as it is
+func (o *Client) ScrapeURL(pageURL string) (ret string, err error) {
+	var parsedURL *url.URL
+	if parsedURL, err = url.Parse(pageURL); err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		err = fmt.Errorf("invalid URL %s, only http and https URLs can be scraped", pageURL)
+		return
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodGet, pageURL, nil); err != nil {
+		err = fmt.Errorf("error creating request: %w", err)
+		return
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.8")
+
+	var resp *http.Response
+	if resp, err = o.HttpClient.Do(req); err != nil {
+		err = fmt.Errorf("error fetching %s: %w", pageURL, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("error fetching %s: status code %d", pageURL, resp.StatusCode)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(resp.Body, MaxPageSize+1)); err != nil {
+		err = fmt.Errorf("error reading response body: %w", err)
+		return
+	}
+	if len(body) > MaxPageSize {
+		err = fmt.Errorf("%s exceeds %d bytes", pageURL, MaxPageSize)
+		return
+	}
+
+	mediaType := "text/html"
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			err = fmt.Errorf("invalid content type %s of %s: %w", contentType, pageURL, err)
+			return
+		}
+	}
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+	case strings.HasPrefix(mediaType, "text/"):
+		ret = string(body)
+		return
+	default:
+		err = fmt.Errorf("%s is %s, only HTML and text pages can be scraped", pageURL, mediaType)
+		return
+	}
+
+	// relative links are resolved against the final URL after redirects
+	var title, content string
+	if title, content, err = converter.HtmlReadabilityArticle(string(body), resp.Request.URL); err != nil {
+		err = fmt.Errorf("could not extract the content of %s: %w", pageURL, err)
+		return
+	}
+	if ret, err = converter.HtmlToMarkdown(content); err != nil {
+		return
+	}
+	if title != "" && !strings.HasPrefix(ret, "# ") {
+		ret = fmt.Sprintf("# %s\n\n%s", title, ret)
+	}
+	return
+}
@@ -0,0 +1,75 @@
+package scraper
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const articleHTML = `<!DOCTYPE html>
+<html><head><title>Release Notes</title><script>var tracking = 1;</script></head>
+<body>
+<nav><a href="/">Home</a> <a href="/about">About</a></nav>
+<article>
+<h1>Release Notes</h1>
+<p>This release brings a number of improvements to the way pages are scraped, which are described in
+detail below so that everyone upgrading knows what to expect from the <a href="/docs/scraping">new scraper</a>.</p>
+<h2>Changes</h2>
+<ul><li>Local scraping by default</li><li>Markdown output</li></ul>
+<table><thead><tr><th>Backend</th><th>Privacy</th></tr></thead>
+<tbody><tr><td>local</td><td>high</td></tr><tr><td>jina</td><td>third party</td></tr></tbody></table>
+<pre><code class="language-bash">fabric -u https://example.com --scraper local</code></pre>
+<p>Another paragraph that is long enough for readability to consider it part of the main content of
+the page, as the heuristics score paragraphs by their length and the number of commas, like this one.</p>
+</article>
+<footer>Copyright</footer>
+</body></html>`
+
+func TestScrapeURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/article":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			io.WriteString(w, articleHTML)
+		case "/notes.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, "plain notes")
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+			io.WriteString(w, "\x89PNG")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient()
+
+	markdown, err := client.ScrapeURL(server.URL + "/article")
+	require.NoError(t, err)
+	assert.Contains(t, markdown, "# Release Notes")
+	assert.Contains(t, markdown, "## Changes")
+	assert.Contains(t, markdown, "[new scraper]("+server.URL+"/docs/scraping)")
+	assert.Contains(t, markdown, "- Local scraping by default")
+	assert.Contains(t, markdown, "| Backend | Privacy |")
+	assert.Contains(t, markdown, "```\nfabric -u https://example.com --scraper local\n```")
+	assert.NotContains(t, markdown, "tracking")
+	assert.NotContains(t, markdown, "Copyright")
+
+	text, err := client.ScrapeURL(server.URL + "/notes.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "plain notes", text)
+
+	_, err = client.ScrapeURL(server.URL + "/image.png")
+	assert.Error(t, err)
+
+	_, err = client.ScrapeURL(server.URL + "/missing")
+	assert.Error(t, err)
+
+	_, err = client.ScrapeURL("file:///etc/passwd")
+	assert.Error(t, err)
+}
@@ -0,0 +1,239 @@
+package subtitles
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Cue is a caption with its time span in seconds
+type Cue struct {
+	Start float64
+	End   float64
+	Text  string
+}
+
+var (
+	timingRe = regexp.MustCompile(`^\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})`)
+	tagRe    = regexp.MustCompile(`<[^>]*>|\{\\[^}]*\}`)
+)
+
+// ReadFile returns the text of a SRT, WebVTT or YouTube XML transcript file,
+// as lines with timestamps when timestamps is set
+func ReadFile(path string, timestamps bool) (ret string, err error) {
+	var content []byte
+	if content, err = os.ReadFile(path); err != nil {
+		return
+	}
+
+	var cues []Cue
+	if cues, err = Parse(string(content), filepath.Ext(path)); err != nil {
+		err = fmt.Errorf("could not parse %s: %w", path, err)
+		return
+	}
+
+	if timestamps {
+		ret = FormatWithTimestamps(cues)
+	} else {
+		ret = FormatText(cues)
+	}
+	return
+}
+
+// Parse reads the cues of a transcript, the format is chosen by the file
+// extension and detected from the content for other extensions
+func Parse(content string, extension string) (ret []Cue, err error) {
+	content = strings.TrimPrefix(strings.ReplaceAll(content, "\r\n", "\n"), "\ufeff")
+
+	switch strings.ToLower(extension) {
+	case ".srt":
+		return ParseSRT(content)
+	case ".vtt":
+		return ParseVTT(content)
+	case ".xml":
+		return ParseYouTubeXML(content)
+	}
+
+	switch trimmed := strings.TrimSpace(content); {
+	case strings.HasPrefix(trimmed, "WEBVTT"):
+		return ParseVTT(content)
+	case strings.HasPrefix(trimmed, "<"):
+		return ParseYouTubeXML(content)
+	}
+	return ParseSRT(content)
+}
+
+// ParseSRT reads SubRip subtitles
+func ParseSRT(content string) (ret []Cue, err error) {
+	ret = parseBlocks(content, false)
+	if len(ret) == 0 && strings.TrimSpace(content) != "" {
+		err = fmt.Errorf("no SRT cues found")
+	}
+	return
+}
+
+// ParseVTT reads WebVTT subtitles. The rolling captions of automatically
+// generated subtitles repeat the previous line, repeated lines are dropped.
+func ParseVTT(content string) (ret []Cue, err error) {
+	if !strings.HasPrefix(strings.TrimSpace(content), "WEBVTT") {
+		err = fmt.Errorf("missing WEBVTT header")
+		return
+	}
+	ret = parseBlocks(content, true)
+	return
+}
+
+// parseBlocks reads the cues of SRT and WebVTT content: blocks separated by
+// blank lines with a timing line, blocks without one like the WebVTT header
+// and NOTE or STYLE blocks are skipped
+func parseBlocks(content string, dropRepeated bool) (ret []Cue) {
+	var previousLine string
+	for _, block := range strings.Split(content, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+
+		timingIndex := -1
+		for i, line := range lines {
+			if timingRe.MatchString(line) {
+				timingIndex = i
+				break
+			}
+		}
+		if timingIndex < 0 {
+			continue
+		}
+
+		match := timingRe.FindStringSubmatch(lines[timingIndex])
+		cue := Cue{Start: parseTimestamp(match[1]), End: parseTimestamp(match[2])}
+
+		var texts []string
+		for _, line := range lines[timingIndex+1:] {
+			if line = cleanText(line); line == "" || dropRepeated && line == previousLine {
+				continue
+			}
+			texts = append(texts, line)
+			previousLine = line
+		}
+		if len(texts) == 0 {
+			continue
+		}
+		cue.Text = strings.Join(texts, " ")
+		ret = append(ret, cue)
+	}
+	return
+}
+
+// ParseYouTubeXML reads the timed text of YouTube transcripts, both the
+// <text start="1.2" dur="3.4"> and the <p t="1200" d="3400"> format
+func ParseYouTubeXML(content string) (ret []Cue, err error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var cue *Cue
+	var text bytes.Buffer
+	for {
+		var token xml.Token
+		if token, err = decoder.Token(); err != nil {
+			if err == io.EOF {
+				err = nil
+				break
+			}
+			err = fmt.Errorf("invalid transcript XML: %w", err)
+			return
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "text":
+				start, duration := attr(element, "start"), attr(element, "dur")
+				cue = &Cue{Start: start, End: start + duration}
+				text.Reset()
+			case "p":
+				start, duration := attr(element, "t")/1000, attr(element, "d")/1000
+				cue = &Cue{Start: start, End: start + duration}
+				text.Reset()
+			}
+		case xml.EndElement:
+			if cue != nil && (element.Name.Local == "text" || element.Name.Local == "p") {
+				// YouTube escapes the entities of the text twice, the unescaped
+				// text is kept as it is: "x &lt; 5" is not markup
+				if cue.Text = collapseSpace(html.UnescapeString(text.String())); cue.Text != "" {
+					ret = append(ret, *cue)
+				}
+				cue = nil
+			}
+		case xml.CharData:
+			if cue != nil {
+				text.Write(element)
+			}
+		}
+	}
+
+	if len(ret) == 0 && strings.TrimSpace(content) != "" {
+		err = fmt.Errorf("no transcript text found")
+	}
+	return
+}
+
+// FormatText returns the text of the cues as one line
+func FormatText(cues []Cue) string {
+	texts := make([]string, len(cues))
+	for i, cue := range cues {
+		texts[i] = cue.Text
+	}
+	return strings.Join(texts, " ")
+}
+
+// FormatWithTimestamps returns a line "[00:01:02 - 00:01:05] text" per cue
+func FormatWithTimestamps(cues []Cue) string {
+	lines := make([]string, len(cues))
+	for i, cue := range cues {
+		lines[i] = fmt.Sprintf("[%s - %s] %s", FormatTimestamp(cue.Start), FormatTimestamp(cue.End), cue.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatTimestamp formats seconds as hh:mm:ss
+func FormatTimestamp(seconds float64) string {
+	total := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total%3600/60, total%60)
+}
+
+// parseTimestamp reads hh:mm:ss,mmm and mm:ss.mmm timestamps
+func parseTimestamp(value string) (ret float64) {
+	for _, part := range strings.Split(strings.Replace(value, ",", ".", 1), ":") {
+		number, _ := strconv.ParseFloat(part, 64)
+		ret = ret*60 + number
+	}
+	return
+}
+
+// cleanText removes the markup of a caption line: tags like <i> or
+// <v Speaker>, SSA overrides like {\an8} and HTML entities
+func cleanText(text string) string {
+	return collapseSpace(html.UnescapeString(tagRe.ReplaceAllString(text, "")))
+}
+
+// collapseSpace joins the words of text with single spaces
+func collapseSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func attr(element xml.StartElement, name string) (ret float64) {
+	for _, attribute := range element.Attr {
+		if attribute.Name.Local == name {
+			ret, _ = strconv.ParseFloat(attribute.Value, 64)
+			return
+		}
+	}
+	return
+}
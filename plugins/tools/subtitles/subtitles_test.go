@@ -0,0 +1,112 @@
+package subtitles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		extension string
+		expected  []Cue
+		err       bool
+	}{
+		{
+			name: "SRT",
+			content: "1\r\n00:00:01,000 --> 00:00:04,500\r\n<i>Hello</i> there,\r\ngeneral\r\n\r\n" +
+				"2\r\n00:01:02,250 --> 00:01:05,000\r\n{\\an8}Second &amp; last\r\n",
+			extension: ".srt",
+			expected: []Cue{
+				{Start: 1, End: 4.5, Text: "Hello there, general"},
+				{Start: 62.25, End: 65, Text: "Second & last"},
+			},
+		},
+		{
+			name: "WebVTT with rolling captions",
+			content: "WEBVTT\nKind: captions\n\nNOTE a comment\n\n" +
+				"intro\n00:01.000 --> 00:03.000 align:start position:0%\n<v Alice>Hello<00:00:01.500><c> world</c>\n\n" +
+				"00:03.000 --> 01:00:05.000\nHello world\nhow are you\n",
+			extension: ".vtt",
+			expected: []Cue{
+				{Start: 1, End: 3, Text: "Hello world"},
+				{Start: 3, End: 3605, Text: "how are you"},
+			},
+		},
+		{
+			name: "YouTube XML",
+			content: `<?xml version="1.0" encoding="utf-8" ?><transcript>` +
+				`<text start="0.5" dur="2.25">It&amp;#39;s here</text>` +
+				`<text start="3" dur="1"></text>` +
+				`<text start="3661" dur="2">&amp;lt;b&amp;gt;Bold&amp;lt;/b&amp;gt;</text></transcript>`,
+			extension: ".xml",
+			expected: []Cue{
+				{Start: 0.5, End: 2.75, Text: "It's here"},
+				{Start: 3661, End: 3663, Text: "<b>Bold</b>"},
+			},
+		},
+		{
+			name: "YouTube XML with comparisons",
+			content: `<transcript><text start="1" dur="1">if x &lt; 5 and y &gt; 3</text>` +
+				`<text start="2" dur="1">if x &amp;lt; 5 and y &amp;gt; 3</text>` +
+				`<text start="3" dur="1">write &amp;amp;lt; for &amp;lt;</text></transcript>`,
+			extension: ".xml",
+			expected: []Cue{
+				{Start: 1, End: 2, Text: "if x < 5 and y > 3"},
+				{Start: 2, End: 3, Text: "if x < 5 and y > 3"},
+				{Start: 3, End: 4, Text: "write &lt; for <"},
+			},
+		},
+		{
+			name:     "YouTube srv3 detected from content",
+			content:  `<timedtext format="3"><body><p t="1500" d="2000">Hi</p></body></timedtext>`,
+			expected: []Cue{{Start: 1.5, End: 3.5, Text: "Hi"}},
+		},
+		{
+			name:     "WebVTT detected from content",
+			content:  "WEBVTT\n\n00:00:02.000 --> 00:00:03.000\nHi\n",
+			expected: []Cue{{Start: 2, End: 3, Text: "Hi"}},
+		},
+		{
+			name:      "WebVTT without header",
+			content:   "00:00:02.000 --> 00:00:03.000\nHi\n",
+			extension: ".vtt",
+			err:       true,
+		},
+		{
+			name:    "Not subtitles",
+			content: "just some text",
+			err:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cues, err := Parse(tc.content, tc.extension)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, cues)
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "talk.srt")
+	require.NoError(t, os.WriteFile(path, []byte("1\n00:00:01,000 --> 00:00:02,000\nHello\n\n2\n00:59:59,000 --> 01:00:01,000\nBye\n"), 0644))
+
+	text, err := ReadFile(path, false)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Bye", text)
+
+	text, err = ReadFile(path, true)
+	require.NoError(t, err)
+	assert.Equal(t, "[00:00:01 - 00:00:02] Hello\n[00:59:59 - 01:00:01] Bye", text)
+}
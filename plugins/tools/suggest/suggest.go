@@ -0,0 +1,159 @@
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// nameWeight repeats the words of the pattern name, they describe the
+	// pattern best
+	nameWeight = 3
+)
+
+// Suggestion is a pattern ranked for an input
+type Suggestion struct {
+	Name        string
+	Description string
+	Score       float64
+}
+
+// RankKeywords ranks the patterns by the BM25 score of the input words in
+// their name, description and prompt
+func RankKeywords(patterns []*fsdb.Pattern, input string) (ret []*Suggestion) {
+	query := uniqueTokens(tokenize(input))
+	if len(query) == 0 {
+		return
+	}
+
+	documents := make([]map[string]int, len(patterns))
+	documentFrequency := map[string]int{}
+	totalLength := 0
+	lengths := make([]int, len(patterns))
+	for i, pattern := range patterns {
+		tokens := tokenize(pattern.SearchText())
+		for range nameWeight - 1 {
+			tokens = append(tokens, tokenize(strings.ReplaceAll(pattern.Name, "_", " "))...)
+		}
+
+		documents[i] = map[string]int{}
+		for _, token := range tokens {
+			documents[i][token]++
+		}
+		for token := range documents[i] {
+			documentFrequency[token]++
+		}
+		lengths[i] = len(tokens)
+		totalLength += len(tokens)
+	}
+	averageLength := float64(totalLength) / float64(max(len(patterns), 1))
+
+	for i, pattern := range patterns {
+		score := 0.0
+		for _, token := range query {
+			frequency := float64(documents[i][token])
+			if frequency == 0 {
+				continue
+			}
+			n := float64(documentFrequency[token])
+			idf := math.Log(1 + (float64(len(patterns))-n+0.5)/(n+0.5))
+			score += idf * frequency * (bm25K1 + 1) /
+				(frequency + bm25K1*(1-bm25B+bm25B*float64(lengths[i])/averageLength))
+		}
+		if score > 0 {
+			ret = append(ret, &Suggestion{Name: pattern.Name, Description: pattern.Summary(), Score: score})
+		}
+	}
+	sortSuggestions(ret)
+	return
+}
+
+// RankEmbeddings ranks the patterns by the similarity of their embeddings
+// to the one of the input, the pattern embeddings are kept in the index of
+// the patterns entity
+func RankEmbeddings(ctx context.Context, entity *fsdb.PatternsEntity, patterns []*fsdb.Pattern, input string,
+	model string, embed fsdb.EmbedFunc) (ret []*Suggestion, err error) {
+
+	var index *fsdb.DocumentIndex
+	if index, err = entity.IndexPatterns(ctx, patterns, model, embed); err != nil {
+		return
+	}
+
+	var vectors [][]float32
+	if vectors, err = embed(ctx, []string{input}); err != nil {
+		return
+	}
+	if len(vectors) != 1 {
+		err = fmt.Errorf("got %d embeddings for the input", len(vectors))
+		return
+	}
+
+	byName := make(map[string]*fsdb.Pattern, len(patterns))
+	for _, pattern := range patterns {
+		byName[pattern.Name] = pattern
+	}
+
+	// a pattern scores with its best matching chunk
+	seen := map[string]bool{}
+	for _, chunk := range index.Search(vectors[0], 0) {
+		pattern, ok := byName[chunk.Source]
+		if !ok || seen[chunk.Source] {
+			continue
+		}
+		seen[chunk.Source] = true
+		ret = append(ret, &Suggestion{Name: pattern.Name, Description: pattern.Summary(), Score: chunk.Score})
+	}
+	sortSuggestions(ret)
+	return
+}
+
+func sortSuggestions(suggestions []*Suggestion) {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Name < suggestions[j].Name
+	})
+}
+
+// tokenize returns the lower case words of the text, short and very common
+// words are dropped
+func tokenize(text string) (ret []string) {
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) > 2 && !stopWords[word] {
+			ret = append(ret, word)
+		}
+	}
+	return
+}
+
+func uniqueTokens(tokens []string) (ret []string) {
+	seen := map[string]bool{}
+	for _, token := range tokens {
+		if !seen[token] {
+			seen[token] = true
+			ret = append(ret, token)
+		}
+	}
+	return
+}
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "you": true, "your": true, "are": true, "with": true,
+	"this": true, "that": true, "from": true, "into": true, "what": true, "how": true, "can": true,
+	"all": true, "any": true, "use": true, "not": true, "but": true, "have": true, "has": true,
+	"was": true, "were": true, "will": true, "would": true, "should": true, "about": true,
+	"want": true, "need": true, "please": true, "give": true, "get": true, "its": true,
+	"output": true, "input": true, "only": true, "each": true, "them": true, "they": true,
+}
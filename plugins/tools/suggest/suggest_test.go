@@ -0,0 +1,68 @@
+package suggest
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+var testPatterns = []*fsdb.Pattern{
+	{Name: "summarize", Description: "Summarize content into a short overview", Pattern: "# IDENTITY\n\nYou summarize articles."},
+	{Name: "write_essay", Pattern: "# IDENTITY\n\nYou write an essay about the topic. Use simple words."},
+	{Name: "create_quiz", Pattern: "You create quiz questions for students learning a subject."},
+}
+
+func TestRankKeywords(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "name match", input: "please summarize this article", want: []string{"summarize"}},
+		{name: "prompt match", input: "questions for my students", want: []string{"create_quiz"}},
+		{name: "no match", input: "the and for", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, suggestion := range RankKeywords(testPatterns, tt.input) {
+				names = append(names, suggestion.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestRankEmbeddings(t *testing.T) {
+	entity := &fsdb.PatternsEntity{IndexFilePath: filepath.Join(t.TempDir(), "pattern_index.json")}
+
+	embedded := 0
+	// the vector counts the words "essay" and "quiz"
+	embed := func(_ context.Context, inputs []string) (ret [][]float32, err error) {
+		embedded += len(inputs)
+		for _, input := range inputs {
+			ret = append(ret, []float32{float32(strings.Count(input, "essay")), float32(strings.Count(input, "quiz")), 0.1})
+		}
+		return
+	}
+
+	suggestions, err := RankEmbeddings(context.Background(), entity, testPatterns, "an essay please", "model", embed)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 3)
+	assert.Equal(t, "write_essay", suggestions[0].Name)
+	assert.Equal(t, "You write an essay about the topic.", suggestions[0].Description)
+
+	// the patterns are embedded once
+	embedded = 0
+	suggestions, err = RankEmbeddings(context.Background(), entity, testPatterns, "a quiz", "model", embed)
+	require.NoError(t, err)
+	assert.Equal(t, "create_quiz", suggestions[0].Name)
+	assert.Equal(t, 1, embedded)
+}
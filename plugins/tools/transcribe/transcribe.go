@@ -0,0 +1,129 @@
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danielmiessler/fabric/plugins/ai"
+	"github.com/danielmiessler/fabric/plugins/tools/subtitles"
+)
+
+const (
+	// MaxFileSize is the upload limit of the OpenAI transcription endpoint,
+	// larger files are split into chunks
+	MaxFileSize = 25 * 1024 * 1024
+	// ChunkDuration is the length of a chunk in seconds, at the chunk bitrate
+	// a chunk stays well below MaxFileSize
+	ChunkDuration = 600
+
+	chunkBitrate = "64k"
+)
+
+// Transcribe returns the transcript of an audio or video file. The chunks of
+// a long file are transcribed one after the other and joined, with
+// timestamps relative to the start of the file.
+func Transcribe(ctx context.Context, transcriber ai.Transcriber, filePath string, model string, language string,
+	timestamps bool) (ret string, err error) {
+
+	var info os.FileInfo
+	if info, err = os.Stat(filePath); err != nil {
+		return
+	}
+
+	chunks := []string{filePath}
+	if info.Size() > MaxFileSize {
+		var tmpDir string
+		if tmpDir, err = os.MkdirTemp("", "fabric-transcribe-"); err != nil {
+			return
+		}
+		defer os.RemoveAll(tmpDir)
+
+		if chunks, err = SplitAudio(ctx, filePath, tmpDir); err != nil {
+			return
+		}
+	}
+
+	var transcriptions []*ai.Transcription
+	for i, chunk := range chunks {
+		var transcription *ai.Transcription
+		if transcription, err = transcriber.Transcribe(ctx, chunk, model, language, timestamps); err != nil {
+			if len(chunks) > 1 {
+				err = fmt.Errorf("could not transcribe chunk %d of %d: %w", i+1, len(chunks), err)
+			}
+			return
+		}
+		transcriptions = append(transcriptions, transcription)
+	}
+
+	ret = FormatTranscriptions(transcriptions, timestamps)
+	return
+}
+
+// SplitAudio encodes the audio of the file with ffmpeg into mono mp3 chunks
+// of ChunkDuration seconds in dir and returns their paths in order
+func SplitAudio(ctx context.Context, filePath string, dir string) (ret []string, err error) {
+	if _, err = exec.LookPath("ffmpeg"); err != nil {
+		err = fmt.Errorf("%s is larger than %d MB, install ffmpeg to split it into chunks", filePath, MaxFileSize/1024/1024)
+		return
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-hide_banner", "-loglevel", "error", "-i", filePath,
+		"-vn", "-ac", "1", "-ar", "16000", "-b:a", chunkBitrate,
+		"-f", "segment", "-segment_time", strconv.Itoa(ChunkDuration), filepath.Join(dir, "chunk_%04d.mp3"))
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		err = fmt.Errorf("ffmpeg could not split %s: %v: %s", filePath, err, strings.TrimSpace(stderr.String()))
+		return
+	}
+
+	if ret, err = filepath.Glob(filepath.Join(dir, "chunk_*.mp3")); err != nil {
+		return
+	}
+	if len(ret) == 0 {
+		err = fmt.Errorf("ffmpeg found no audio in %s", filePath)
+		return
+	}
+	sort.Strings(ret)
+	return
+}
+
+// FormatTranscriptions joins the transcriptions of consecutive chunks. With
+// timestamps every segment is a line "[00:01:02 - 00:01:05] text", shifted
+// by the duration of the chunks before it; the text of a chunk without
+// segments, from endpoints ignoring them, spans the whole chunk.
+func FormatTranscriptions(transcriptions []*ai.Transcription, timestamps bool) string {
+	if !timestamps {
+		var texts []string
+		for _, transcription := range transcriptions {
+			if transcription.Text != "" {
+				texts = append(texts, transcription.Text)
+			}
+		}
+		return strings.Join(texts, "\n\n")
+	}
+
+	var cues []subtitles.Cue
+	offset := 0.0
+	for _, transcription := range transcriptions {
+		duration := transcription.Duration
+		if duration == 0 {
+			duration = ChunkDuration
+		}
+		for _, segment := range transcription.Segments {
+			cues = append(cues, subtitles.Cue{Start: offset + segment.Start, End: offset + segment.End, Text: segment.Text})
+		}
+		if len(transcription.Segments) == 0 && transcription.Text != "" {
+			cues = append(cues, subtitles.Cue{Start: offset, End: offset + duration, Text: transcription.Text})
+		}
+		offset += duration
+	}
+	return subtitles.FormatWithTimestamps(cues)
+}
@@ -0,0 +1,80 @@
+package transcribe
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielmiessler/fabric/plugins/ai"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTranscriber struct {
+	files []string
+}
+
+func (o *mockTranscriber) Transcribe(_ context.Context, filePath string, _ string, _ string, _ bool) (*ai.Transcription, error) {
+	o.files = append(o.files, filePath)
+	return &ai.Transcription{
+		Text:     "Hello there.",
+		Duration: 3,
+		Segments: []ai.TranscriptionSegment{{Start: 0, End: 3, Text: "Hello there."}},
+	}, nil
+}
+
+func TestTranscribe(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "meeting.mp3")
+	assert.NoError(t, os.WriteFile(filePath, []byte("audio"), 0644))
+
+	transcriber := &mockTranscriber{}
+	transcript, err := Transcribe(context.Background(), transcriber, filePath, "whisper-1", "", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "[00:00:00 - 00:00:03] Hello there.", transcript)
+	// small files are sent as they are
+	assert.Equal(t, []string{filePath}, transcriber.files)
+}
+
+func TestFormatTranscriptions(t *testing.T) {
+	transcriptions := []*ai.Transcription{
+		{
+			Text:     "First chunk.",
+			Duration: 600,
+			Segments: []ai.TranscriptionSegment{{Start: 0, End: 4, Text: "First"}, {Start: 595.5, End: 600, Text: "chunk."}},
+		},
+		{
+			Text:     "Second chunk.",
+			Duration: 120,
+			Segments: []ai.TranscriptionSegment{{Start: 1, End: 3661, Text: "Second chunk."}},
+		},
+		{
+			Text:     "Third chunk without segments.",
+			Duration: 30,
+		},
+	}
+
+	tests := []struct {
+		name       string
+		timestamps bool
+		expected   string
+	}{
+		{
+			name:     "Text",
+			expected: "First chunk.\n\nSecond chunk.\n\nThird chunk without segments.",
+		},
+		{
+			name:       "Timestamps shifted by the previous chunks",
+			timestamps: true,
+			expected: "[00:00:00 - 00:00:04] First\n" +
+				"[00:09:55 - 00:10:00] chunk.\n" +
+				"[00:10:01 - 01:11:01] Second chunk.\n" +
+				"[00:12:00 - 00:12:30] Third chunk without segments.",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, FormatTranscriptions(transcriptions, tc.timestamps))
+		})
+	}
+}
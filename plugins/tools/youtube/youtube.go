@@ -18,6 +18,7 @@ import (
 
 	"github.com/anaskhan96/soup"
 	"github.com/danielmiessler/fabric/plugins"
+	"github.com/danielmiessler/fabric/plugins/tools/subtitles"
 	"google.golang.org/api/option"
 	"google.golang.org/api/youtube/v3"
 )
@@ -96,64 +97,33 @@ func (o *YouTube) GrabTranscriptForUrl(url string, language string) (ret string,
 }
 
 func (o *YouTube) GrabTranscript(videoId string, language string) (ret string, err error) {
-	var transcript string
-	if transcript, err = o.GrabTranscriptBase(videoId, language); err != nil {
-		err = fmt.Errorf("transcript not available. (%v)", err)
+	var cues []subtitles.Cue
+	if cues, err = o.grabTranscriptCues(videoId, language); err != nil {
 		return
 	}
+	ret = subtitles.FormatText(cues)
+	return
+}
 
-	// Parse the XML transcript
-	doc := soup.HTMLParse(transcript)
-	// Extract the text content from the <text> tags
-	textTags := doc.FindAll("text")
-	var textBuilder strings.Builder
-	for _, textTag := range textTags {
-		textBuilder.WriteString(strings.ReplaceAll(textTag.Text(), "&#39;", "'"))
-		textBuilder.WriteString(" ")
-		ret = textBuilder.String()
+func (o *YouTube) GrabTranscriptWithTimestamps(videoId string, language string) (ret string, err error) {
+	var cues []subtitles.Cue
+	if cues, err = o.grabTranscriptCues(videoId, language); err != nil {
+		return
 	}
+	ret = subtitles.FormatWithTimestamps(cues)
 	return
 }
 
-func (o *YouTube) GrabTranscriptWithTimestamps(videoId string, language string) (ret string, err error) {
+func (o *YouTube) grabTranscriptCues(videoId string, language string) (ret []subtitles.Cue, err error) {
 	var transcript string
 	if transcript, err = o.GrabTranscriptBase(videoId, language); err != nil {
 		err = fmt.Errorf("transcript not available. (%v)", err)
 		return
 	}
-
-	// Parse the XML transcript
-	doc := soup.HTMLParse(transcript)
-	// Extract the text content from the <text> tags
-	textTags := doc.FindAll("text")
-	var textBuilder strings.Builder
-	for _, textTag := range textTags {
-		// Extract the start and duration attributes
-		start := textTag.Attrs()["start"]
-		dur := textTag.Attrs()["dur"]
-		end := fmt.Sprintf("%f", parseFloat(start)+parseFloat(dur))
-		// Format the timestamps
-		startFormatted := formatTimestamp(parseFloat(start))
-		endFormatted := formatTimestamp(parseFloat(end))
-		text := strings.ReplaceAll(textTag.Text(), "&#39;", "'")
-		textBuilder.WriteString(fmt.Sprintf("[%s - %s] %s\n", startFormatted, endFormatted, text))
-	}
-	ret = textBuilder.String()
+	ret, err = subtitles.ParseYouTubeXML(transcript)
 	return
 }
 
-func parseFloat(s string) float64 {
-	f, _ := strconv.ParseFloat(s, 64)
-	return f
-}
-
-func formatTimestamp(seconds float64) string {
-	hours := int(seconds) / 3600
-	minutes := (int(seconds) % 3600) / 60
-	secs := int(seconds) % 60
-	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, secs)
-}
-
 func (o *YouTube) GrabTranscriptBase(videoId string, language string) (ret string, err error) {
 	if err = o.initService(); err != nil {
 		return "", fmt.Errorf("error initializing YouTube service: %v", err)
@@ -119,9 +119,11 @@ func (h *ChatHandler) HandleChat(c *gin.Context) {
 						Content: p.UserInput,
 					},
 					PatternName: p.PatternName,
-					ContextName: p.ContextName,
 					Language:    request.Language, // Pass the language field
 				}
+				if p.ContextName != "" {
+					chatReq.ContextNames = []string{p.ContextName}
+				}
 
 				opts := &common.ChatOptions{
 					Model:            p.Model,